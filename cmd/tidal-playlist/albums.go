@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var albumsLimit int
+
+var albumsCmd = &cobra.Command{
+	Use:   "albums <artist-id-or-name>",
+	Short: "List the albums of an artist",
+	Long: `List the albums of an artist exactly as the playlist builder sees them,
+with their IDs, titles, types and release dates. Useful for debugging filters.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+
+		ctx := cmd.Context()
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		artistID, err := client.ResolveArtistID(ctx, args[0])
+		if err != nil {
+			return err
+		}
+
+		albums, err := client.GetArtistAlbums(ctx, artistID, albumsLimit)
+		if err != nil {
+			return err
+		}
+
+		if len(albums) == 0 {
+			fmt.Printf("No albums found for artist %s\n", artistID)
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tTYPE\tRELEASED\tTITLE")
+		for _, album := range albums {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", album.ID, album.Type, album.ReleaseDate, album.DisplayTitle())
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	albumsCmd.Flags().IntVar(&albumsLimit, "limit", 100, "maximum number of albums to list")
+
+	rootCmd.AddCommand(albumsCmd)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var artistsJSON bool
+
+var artistsCmd = &cobra.Command{
+	Use:   "artists",
+	Short: "List your favorite artists",
+	Long: `List your favorite artists with their names and IDs, e.g. to copy them
+into the blacklist or whitelist.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+
+		ctx := cmd.Context()
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		favorites, err := client.GetFavoriteArtists(ctx)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]string, 0, len(favorites))
+		for _, favorite := range favorites {
+			ids = append(ids, favorite.ID)
+		}
+
+		artists, err := client.GetArtists(ctx, ids)
+		if err != nil {
+			return err
+		}
+
+		names := make(map[string]string, len(artists))
+		for _, artist := range artists {
+			names[artist.ID] = artist.Attributes.Name
+		}
+
+		entries := make([]artistEntry, 0, len(ids))
+		for _, id := range ids {
+			entries = append(entries, artistEntry{ID: id, Name: names[id]})
+		}
+		sort.SliceStable(entries, func(i, j int) bool {
+			return strings.ToLower(entries[i].Name) < strings.ToLower(entries[j].Name)
+		})
+
+		if artistsJSON {
+			return printJSON(artistsOutput{SchemaVersion: jsonSchemaVersion, Artists: entries})
+		}
+
+		if len(entries) == 0 {
+			fmt.Println("No favorite artists found")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME")
+		for _, entry := range entries {
+			fmt.Fprintf(w, "%s\t%s\n", entry.ID, entry.Name)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	artistsCmd.Flags().BoolVar(&artistsJSON, "json", false, "print the artists as JSON")
+
+	rootCmd.AddCommand(artistsCmd)
+}
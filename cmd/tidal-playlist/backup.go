@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/aligator/tidal-playlist/internal/backup"
+	"github.com/aligator/tidal-playlist/internal/models"
+	"github.com/spf13/cobra"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup <dir>",
+	Short: "Back up all playlists to JSON files",
+	Long: `Write every playlist of your account, with its metadata and tracks, to a
+JSON file in the given directory. Run this before destructive operations to
+have a snapshot to restore from.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+
+		ctx := cmd.Context()
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		playlists, err := client.GetUserPlaylists(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to fetch playlists: %w", err)
+		}
+
+		for i, playlist := range playlists {
+			fmt.Printf("[%d/%d] %s\n", i+1, len(playlists), playlist.GetTitle())
+
+			items, err := client.GetPlaylistItems(ctx, playlist.GetID())
+			if err != nil {
+				return fmt.Errorf("failed to back up '%s': %w", playlist.GetTitle(), err)
+			}
+
+			var trackIDs []string
+			for _, item := range items {
+				if item.Type == "tracks" {
+					trackIDs = append(trackIDs, item.ID)
+				}
+			}
+
+			// The ISRCs allow to find the tracks again should their IDs change.
+			tracks, err := client.GetTracks(ctx, trackIDs)
+			if err != nil {
+				return fmt.Errorf("failed to back up '%s': %w", playlist.GetTitle(), err)
+			}
+			details := make(map[string]backup.Track, len(tracks))
+			for _, track := range tracks {
+				details[track.ID] = backup.Track{ID: track.ID, ISRC: track.ISRC, Title: track.Title}
+			}
+
+			entry := backup.Playlist{
+				ID:          playlist.GetID(),
+				Name:        playlist.GetTitle(),
+				Description: playlist.Description,
+				Created:     playlist.Created,
+				LastUpdated: playlist.LastUpdated,
+				ImageURL:    models.PreferredImage(playlist.Images, cfg.Export.ImageSize),
+				Tracks:      make([]backup.Track, 0, len(trackIDs)),
+			}
+			for _, trackID := range trackIDs {
+				track, ok := details[trackID]
+				if !ok {
+					// Unavailable tracks are kept with their ID only.
+					track = backup.Track{ID: trackID}
+				}
+				entry.Tracks = append(entry.Tracks, track)
+			}
+
+			if _, err := backup.Write(args[0], entry); err != nil {
+				return fmt.Errorf("failed to write backup of '%s': %w", playlist.GetTitle(), err)
+			}
+		}
+
+		fmt.Printf("\n✓ Backed up %d playlists to %s\n", len(playlists), args[0])
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+}
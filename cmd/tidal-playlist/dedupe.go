@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/aligator/tidal-playlist/internal/models"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dedupeDryRun bool
+	dedupeYes    bool
+)
+
+var dedupeCmd = &cobra.Command{
+	Use:   "dedupe-playlists",
+	Short: "Merge playlists that share the same name",
+	Long: `Find playlists with the same name, merge their tracks into the oldest
+one and delete the others. Tracks already in the oldest playlist are not
+added twice. Duplicates containing items other than tracks, e.g. videos, are
+kept, as only tracks can be merged.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+
+		ctx := cmd.Context()
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		playlists, err := client.GetUserPlaylists(ctx)
+		if err != nil {
+			return err
+		}
+
+		// Group playlists by name, keeping the order of first appearance.
+		var names []string
+		groups := make(map[string][]models.Playlist)
+		for _, playlist := range playlists {
+			name := playlist.GetTitle()
+			if _, ok := groups[name]; !ok {
+				names = append(names, name)
+			}
+			groups[name] = append(groups[name], playlist)
+		}
+
+		merged := 0
+		for _, name := range names {
+			group := groups[name]
+			if len(group) < 2 {
+				continue
+			}
+
+			// Oldest first
+			slices.SortStableFunc(group, func(a, b models.Playlist) int {
+				return a.Created.Compare(b.Created)
+			})
+			target, duplicates := group[0], group[1:]
+
+			targetItems, err := client.GetPlaylistItems(ctx, target.GetID())
+			if err != nil {
+				return err
+			}
+
+			present := make(map[string]bool, len(targetItems))
+			for _, item := range targetItems {
+				present[item.ID] = true
+			}
+
+			// Only tracks can be added to the oldest playlist, so duplicates
+			// containing anything else, e.g. videos, are kept.
+			var missing []string
+			var deletable []models.Playlist
+			for _, duplicate := range duplicates {
+				items, err := client.GetPlaylistItems(ctx, duplicate.GetID())
+				if err != nil {
+					return err
+				}
+
+				unmergeable := 0
+				for _, item := range items {
+					if item.Type != "tracks" {
+						unmergeable++
+						continue
+					}
+					if !present[item.ID] {
+						present[item.ID] = true
+						missing = append(missing, item.ID)
+					}
+				}
+				if unmergeable > 0 {
+					fmt.Printf("Warning: keeping %s, it contains %d items other than tracks that can't be merged\n", duplicate.GetID(), unmergeable)
+					continue
+				}
+				deletable = append(deletable, duplicate)
+			}
+
+			fmt.Printf("'%s': %d playlists, %d tracks to merge into %s, %d duplicates to delete\n", name, len(group), len(missing), target.GetID(), len(deletable))
+			if dedupeDryRun {
+				continue
+			}
+			if !dedupeYes && !confirm(fmt.Sprintf("Merge and delete %d duplicates of '%s'?", len(deletable), name)) {
+				continue
+			}
+
+			if len(missing) > 0 {
+				if err := client.AddTracksToPlaylist(ctx, target.GetID(), missing); err != nil {
+					return fmt.Errorf("failed to merge tracks into '%s': %w", name, err)
+				}
+			}
+
+			for _, duplicate := range deletable {
+				if err := client.DeletePlaylist(ctx, duplicate.GetID()); err != nil {
+					return err
+				}
+			}
+
+			merged++
+		}
+
+		if dedupeDryRun {
+			fmt.Println("\nDry run, nothing was changed.")
+			return nil
+		}
+
+		fmt.Printf("\n✓ Merged %d groups of duplicate playlists\n", merged)
+		return nil
+	},
+}
+
+func init() {
+	dedupeCmd.Flags().BoolVar(&dedupeDryRun, "dry-run", false, "only show which playlists would be merged")
+	dedupeCmd.Flags().BoolVarP(&dedupeYes, "yes", "y", false, "do not ask for confirmation")
+
+	rootCmd.AddCommand(dedupeCmd)
+}
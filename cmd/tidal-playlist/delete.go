@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aligator/tidal-playlist/internal/api"
+	"github.com/aligator/tidal-playlist/internal/models"
+	"github.com/spf13/cobra"
+)
+
+var (
+	deleteForce bool
+	deleteByID  bool
+	deleteFuzzy bool
+)
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete <name-or-id>",
+	Short: "Delete playlists by name or ID",
+	Long: `Delete all playlists with the given name. If no playlist has that name,
+the argument is used as playlist ID. With --id, the playlist with that ID is
+deleted without looking up names.
+
+If several playlists share the name, they are listed and you are asked for
+confirmation unless --force is passed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+
+		ctx := cmd.Context()
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		var playlists []models.Playlist
+		if deleteByID {
+			playlist, err := client.GetPlaylist(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			playlists = append(playlists, *playlist)
+		} else {
+			playlists, err = client.FindAllPlaylistsByName(ctx, args[0])
+			if err != nil {
+				return fmt.Errorf("failed to find playlist: %w", err)
+			}
+		}
+
+		if len(playlists) == 0 && deleteFuzzy {
+			playlist, err := findPlaylist(ctx, client, args[0], true)
+			if err != nil {
+				return err
+			}
+			playlists = append(playlists, *playlist)
+		} else if len(playlists) == 0 {
+			// Not a name, so try it as ID.
+			// The API rejects names that aren't valid IDs as bad requests.
+			playlist, err := client.GetPlaylist(ctx, args[0])
+			var apiErr *api.APIError
+			if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusBadRequest) {
+				return fmt.Errorf("no playlist named '%s' found", args[0])
+			}
+			if err != nil {
+				return fmt.Errorf("failed to look up '%s' as playlist ID: %w", args[0], err)
+			}
+			playlists = append(playlists, *playlist)
+		}
+
+		if len(playlists) > 1 && !deleteForce {
+			fmt.Printf("Found %d playlists named '%s':\n", len(playlists), args[0])
+			for _, playlist := range playlists {
+				fmt.Printf("  %s (%s, %d tracks)\n", playlist.GetTitle(), playlist.GetID(), playlist.NumberOfTracks)
+			}
+			if !confirm(fmt.Sprintf("Delete all %d playlists?", len(playlists))) {
+				fmt.Println("Skipped")
+				return nil
+			}
+		}
+
+		for _, playlist := range playlists {
+			if err := client.DeletePlaylist(ctx, playlist.GetID()); err != nil {
+				return fmt.Errorf("failed to delete playlist %s: %w", playlist.GetID(), err)
+			}
+		}
+
+		fmt.Printf("✓ Deleted %d playlists\n", len(playlists))
+		return nil
+	},
+}
+
+func init() {
+	deleteCmd.Flags().BoolVar(&deleteForce, "force", false, "delete all playlists with the name without asking")
+	deleteCmd.Flags().BoolVar(&deleteByID, "id", false, "treat the argument as playlist ID and skip the name lookup")
+	deleteCmd.Flags().BoolVar(&deleteFuzzy, "fuzzy", false, "offer playlists with a similar name if none matches exactly")
+
+	rootCmd.AddCommand(deleteCmd)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/aligator/tidal-playlist/internal/export"
+	"github.com/aligator/tidal-playlist/internal/models"
+	"github.com/spf13/cobra"
+)
+
+var exportFuzzy bool
+
+var exportCmd = &cobra.Command{
+	Use:   "export <playlist-name> <path>",
+	Short: "Export a playlist as M3U8 file",
+	Long: `Write the tracks of an existing playlist as extended M3U8 file, with the
+duration and "Artist - Title" of every track, e.g. to compare it with a local
+music library.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+
+		ctx := cmd.Context()
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		playlist, err := findPlaylist(ctx, client, args[0], exportFuzzy)
+		if err != nil {
+			return err
+		}
+
+		items, err := client.GetPlaylistItems(ctx, playlist.GetID())
+		if err != nil {
+			return fmt.Errorf("failed to get playlist items: %w", err)
+		}
+
+		ids := make([]string, 0, len(items))
+		for _, item := range items {
+			if item.Type == "tracks" {
+				ids = append(ids, item.ID)
+			}
+		}
+
+		fetched, err := client.GetTracks(ctx, ids)
+		if err != nil {
+			return err
+		}
+		byID := make(map[string]models.Track, len(fetched))
+		for _, track := range fetched {
+			byID[track.ID] = track
+		}
+
+		// Keep the order of the playlist.
+		tracks := make([]models.Track, 0, len(ids))
+		for _, id := range ids {
+			if track, ok := byID[id]; ok {
+				tracks = append(tracks, track)
+			}
+		}
+		if missing := len(ids) - len(tracks); missing > 0 {
+			fmt.Printf("Note: %d tracks are not available in your country and are left out\n", missing)
+		}
+
+		if err := export.WriteM3U(args[1], playlist.GetTitle(), tracks, cfg.Export.BOM); err != nil {
+			return fmt.Errorf("failed to export playlist: %w", err)
+		}
+
+		fmt.Printf("✓ Exported %d tracks of '%s' to %s\n", len(tracks), playlist.GetTitle(), args[1])
+		return nil
+	},
+}
+
+func init() {
+	exportCmd.Flags().BoolVar(&exportFuzzy, "fuzzy", false, "offer playlists with a similar name if none matches exactly")
+
+	rootCmd.AddCommand(exportCmd)
+}
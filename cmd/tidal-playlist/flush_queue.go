@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/aligator/tidal-playlist/internal/builder"
+	"github.com/spf13/cobra"
+)
+
+var flushQueueDryRun bool
+
+var flushQueueCmd = &cobra.Command{
+	Use:   "flush-queue <queue-file>",
+	Short: "Write the playlists queued with create --defer-write",
+	Long: `Write the playlists queued with "create --defer-write" to Tidal, in the
+order they were queued and with the configured rate limits. Every written
+playlist is removed from the queue file right away, so an interrupted flush
+can simply be run again.
+
+Queued playlists without a description keep the description of the existing
+playlist, like "create" does.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+
+		ctx := cmd.Context()
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		return builder.NewBuilder(client, cfg).FlushQueue(ctx, args[0], flushQueueDryRun)
+	},
+}
+
+func init() {
+	flushQueueCmd.Flags().BoolVar(&flushQueueDryRun, "dry-run", false, "only list the queued playlists")
+
+	rootCmd.AddCommand(flushQueueCmd)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var listJSON bool
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List your playlists",
+	Long: `List all playlists of your account with their track count and ID.
+With --verbose, the description and creator are printed as well.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+
+		ctx := cmd.Context()
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		playlists, err := client.GetUserPlaylists(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get playlists: %w", err)
+		}
+
+		if listJSON {
+			return printJSON(newPlaylistsOutput(playlists))
+		}
+
+		if len(playlists) == 0 {
+			fmt.Println("No playlists found")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		if verbose {
+			fmt.Fprintln(w, "TITLE\tTRACKS\tACCESS\tID\tCREATOR\tDESCRIPTION")
+		} else {
+			fmt.Fprintln(w, "TITLE\tTRACKS\tACCESS\tID")
+		}
+		for _, playlist := range playlists {
+			if verbose {
+				creator := playlist.Creator.Name
+				if creator == "" {
+					creator = playlist.Creator.ID
+				}
+				fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\n", playlist.GetTitle(), playlist.NumberOfTracks, playlist.AccessType, playlist.GetID(), creator, playlist.Description)
+			} else {
+				fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", playlist.GetTitle(), playlist.NumberOfTracks, playlist.AccessType, playlist.GetID())
+			}
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	listCmd.Flags().BoolVar(&listJSON, "json", false, "print the playlists as JSON")
+
+	rootCmd.AddCommand(listCmd)
+}
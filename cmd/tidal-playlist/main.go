@@ -2,21 +2,60 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/aligator/tidal-playlist/internal/api"
 	"github.com/aligator/tidal-playlist/internal/builder"
 	"github.com/aligator/tidal-playlist/internal/config"
+	"github.com/aligator/tidal-playlist/internal/recipe"
+	"github.com/aligator/tidal-playlist/internal/version"
 	"github.com/spf13/cobra"
 )
 
 var (
-	configPath   string
-	playlistName string
-	count        int
-	dryRun       bool
-	verbose      bool
+	configPath    string
+	accessToken   string
+	skipAuthCheck bool
+	playlistName  string
+	namePrefix    string
+	nameSuffix    string
+	description   string
+	count         string
+	minTracks     int
+	maxAlbums     int
+	addRetries    int
+	ifChanged     bool
+	explainSkips  bool
+	includeIDs    []string
+	excludeIDs    []string
+	seed          int64
+	appendFavs    bool
+	appendMode    bool
+	retryFailed   bool
+	saveRecipe    string
+	fromRecipe    string
+	deadline      time.Duration
+	benchmark     bool
+	exportPath    string
+	deferWrite    string
+	outputFormat  string
+	estimate      bool
+	dryRun        bool
+	commit        bool
+	alsoLike      bool
+	cleanup       bool
+	verbose       bool
+	versionJSON   bool
+	profile       string
+	profiles      []string
 )
 
 var rootCmd = &cobra.Command{
@@ -31,9 +70,9 @@ var authCmd = &cobra.Command{
 	Short: "Authenticate with Tidal",
 	Long:  "Authenticate with your Tidal account.",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		cfg, err := config.Load(configPath)
+		cfg, err := loadConfig()
 		if err != nil {
-			return fmt.Errorf("failed to load config: %w", err)
+			return err
 		}
 
 		if err := cfg.Validate(); err != nil {
@@ -41,10 +80,17 @@ var authCmd = &cobra.Command{
 		}
 
 		authMgr := api.NewAuthManager(cfg.Tidal.ClientID, cfg.Tidal.ClientSecret)
+		if profile != "" {
+			authMgr.UseProfile(profile)
+		}
+		if cfg.Tidal.TokenStore == "keyring" {
+			authMgr.UseKeyring()
+		}
+		authMgr.UseCallbackPort(cfg.Tidal.CallbackPort)
 
 		fmt.Println("Starting OAuth authorization...")
 		fmt.Println("Opening browser for Tidal login...")
-		token, err := authMgr.Login(context.Background())
+		token, err := authMgr.Login(cmd.Context())
 		if err != nil {
 			return fmt.Errorf("authentication failed: %w", err)
 		}
@@ -56,48 +102,244 @@ var authCmd = &cobra.Command{
 }
 
 var createCmd = &cobra.Command{
-	Use:   "create [playlist-name]",
+	Use:   "create [playlist-name...]",
 	Short: "Create or update a playlist",
 	Long: `Create a new playlist or update an existing one with tracks from
 all your favorite artists. If a playlist with the same name exists,
-it will be cleared and updated with new tracks.`,
-	Args: cobra.MaximumNArgs(1),
+it will be cleared and updated with new tracks.
+
+Several names can be given to build multiple playlists in one run, with a
+short pause (playlist.pause_between) between them.`,
+	Args: cobra.ArbitraryArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		cfg, err := config.Load(configPath)
+		cfg, err := loadConfig()
 		if err != nil {
-			return fmt.Errorf("failed to load config: %w", err)
+			return err
+		}
+
+		// Override config with CLI flags if provided
+		if count != "" {
+			if err := applyCount(cfg, count); err != nil {
+				return err
+			}
+		}
+		if err := applyArtistIDs(cfg, includeIDs, excludeIDs); err != nil {
+			return err
+		}
+		if minTracks > 0 {
+			cfg.Playlist.MinTracks = minTracks
+		}
+		if maxAlbums > 0 {
+			cfg.Playlist.MaxAlbumsFetched = maxAlbums
+		}
+		if alsoLike {
+			cfg.Playlist.AlsoLike = true
+		}
+		if cleanup {
+			cfg.Playlist.CleanupOnFailure = true
+		}
+		if addRetries > 0 {
+			cfg.Playlist.AddRetries = addRetries
+		}
+		if ifChanged {
+			cfg.Playlist.OnlyIfChanged = true
+		}
+		if appendMode {
+			cfg.Playlist.Mode = "append"
+		}
+		if explainSkips {
+			cfg.Filters.ExplainSkips = true
+		}
+		if seed != 0 {
+			cfg.Playlist.Seed = seed
+		}
+		if len(profiles) > 0 {
+			cfg.Playlist.Profiles = profiles
+		}
+		if namePrefix != "" {
+			cfg.Playlist.NamePrefix = namePrefix
+		}
+		if nameSuffix != "" {
+			cfg.Playlist.NameSuffix = nameSuffix
+		}
+		if description != "" {
+			cfg.Playlist.Description = description
 		}
 
 		if err := cfg.Validate(); err != nil {
 			return fmt.Errorf("invalid config: %w", err)
 		}
 
-		// Override config with CLI flags if provided
-		if count > 0 {
-			cfg.Playlist.Count = count
+		if dryRun && commit {
+			return fmt.Errorf("--dry-run and --commit can't be used together")
+		}
+		preview := dryRun || (cfg.Safety.DryRunByDefault && !commit)
+		if appendFavs && cfg.Playlist.Source != "favorites" {
+			return fmt.Errorf("--append-favorites requires source 'favorites'")
+		}
+
+		// Determine playlist names
+		names := args
+		if len(names) == 0 && playlistName != "" {
+			names = []string{playlistName}
+		}
+		if len(names) == 0 {
+			names = []string{cfg.Playlist.DefaultName}
+		}
+		if (saveRecipe != "" || fromRecipe != "" || exportPath != "") && len(names) > 1 {
+			return fmt.Errorf("--save-recipe, --from-recipe and --export can only be used with a single playlist")
+		}
+		if exportPath != "" && appendFavs {
+			return fmt.Errorf("--export and --append-favorites can't be used together")
+		}
+		if estimate && (appendFavs || fromRecipe != "") {
+			return fmt.Errorf("--estimate can't be used with --append-favorites or --from-recipe")
+		}
+		if retryFailed && (appendFavs || fromRecipe != "" || saveRecipe != "" || exportPath != "" || estimate) {
+			return fmt.Errorf("--retry-failed can't be used with --append-favorites, --from-recipe, --save-recipe, --export or --estimate")
 		}
 
-		// Determine playlist name
-		name := cfg.Playlist.DefaultName
-		if len(args) > 0 {
-			name = args[0]
-		} else if playlistName != "" {
-			name = playlistName
+		if outputFormat != "text" && outputFormat != "json" {
+			return fmt.Errorf("--output must be 'text' or 'json'")
+		}
+		if outputFormat == "json" && (appendFavs || retryFailed || estimate || benchmark) {
+			return fmt.Errorf("--output json can't be used with --append-favorites, --retry-failed, --estimate or --benchmark")
+		}
+		if deferWrite != "" && (appendFavs || retryFailed || estimate) {
+			return fmt.Errorf("--defer-write can't be used with --append-favorites, --retry-failed or --estimate")
+		}
+		if deferWrite != "" && (cfg.Playlist.Mode == "append" || cfg.Playlist.KeepHistory > 0 || cfg.Playlist.AlsoLike) {
+			return fmt.Errorf("--defer-write can't be used with playlist.mode 'append', keep_history or also_like")
+		}
+
+		var savedRecipe *recipe.Recipe
+		if fromRecipe != "" {
+			if appendFavs {
+				return fmt.Errorf("--from-recipe and --append-favorites can't be used together")
+			}
+			savedRecipe, err = recipe.Read(fromRecipe)
+			if err != nil {
+				return fmt.Errorf("failed to read recipe: %w", err)
+			}
+		}
+
+		// With JSON output or a benchmark report, stdout only carries the
+		// JSON document.
+		var jsonOut *os.File
+		if outputFormat == "json" || benchmark {
+			jsonOut = progressToStderr()
+		}
+		output := createOutput{SchemaVersion: jsonSchemaVersion, Playlists: []buildOutput{}}
+
+		startedAt := time.Now()
+
+		// The deadline bounds the whole run, over all playlists.
+		var runDeadline time.Time
+		if deadline < 0 {
+			return fmt.Errorf("--deadline must not be negative")
+		} else if deadline > 0 {
+			runDeadline = time.Now().Add(deadline)
 		}
 
 		// Create API client
-		authMgr := api.NewAuthManager(cfg.Tidal.ClientID, cfg.Tidal.ClientSecret)
-		client := api.NewClient(authMgr, cfg)
+		ctx := cmd.Context()
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		delivered := 0
+		for i, name := range names {
+			if len(names) > 1 {
+				if i > 0 {
+					select {
+					case <-time.After(cfg.Playlist.PauseBetween):
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				}
+				fmt.Printf("\n=== Building %d/%d: '%s' ===\n", i+1, len(names), name)
+			}
+
+			// Every playlist gets a fresh builder, so nothing carries over.
+			b := builder.NewBuilder(client, cfg)
+			for _, profileName := range cfg.Playlist.Profiles {
+				authMgr := api.NewAuthManager(cfg.Tidal.ClientID, cfg.Tidal.ClientSecret)
+				authMgr.UseProfile(profileName)
+				if cfg.Tidal.TokenStore == "keyring" {
+					authMgr.UseKeyring()
+				}
+				if skipAuthCheck {
+					authMgr.SkipAuthCheck()
+				}
+				b.AddFavoritesSource(profileName, api.NewClient(authMgr, cfg))
+			}
 
-		// Create builder
-		b := builder.NewBuilder(client, cfg)
+			if estimate {
+				e, err := b.Estimate(ctx, preview)
+				if err != nil {
+					return fmt.Errorf("failed to estimate '%s': %w", name, err)
+				}
+				fmt.Printf("\nEstimate for '%s':\n", name)
+				fmt.Printf("  Artists after filtering: %d\n", e.Artists)
+				fmt.Printf("  Tracks to collect:       %d (including spares)\n", e.Slots)
+				fmt.Printf("  API requests:            ~%d\n", e.Requests)
+				fmt.Printf("  Runtime:                 at least %s at %g requests per second\n",
+					e.Duration.Round(time.Second), cfg.Tidal.RequestsPerSecond)
+				continue
+			}
 
-		// Build playlist
-		ctx := context.Background()
-		if err := b.BuildPlaylist(ctx, name, dryRun); err != nil {
-			return fmt.Errorf("failed to build playlist: %w", err)
+			if !runDeadline.IsZero() {
+				b.SetDeadline(runDeadline)
+			}
+			if savedRecipe != nil {
+				b.UseRecipe(savedRecipe)
+			}
+			if saveRecipe != "" {
+				b.SaveRecipe(saveRecipe)
+			}
+			if exportPath != "" {
+				b.ExportM3U(exportPath)
+			}
+			if deferWrite != "" {
+				b.DeferWrite(deferWrite)
+			}
+
+			// Build playlist
+			var result *builder.BuildResult
+			if appendFavs {
+				err = b.AppendNewFavorites(ctx, name, preview)
+			} else if retryFailed {
+				err = b.RetryFailed(ctx, name, preview)
+			} else {
+				result, err = b.BuildPlaylist(ctx, name, preview)
+			}
+			if errors.Is(err, builder.ErrUnchanged) {
+				fmt.Printf("No changes in your favorite artists since '%s' was last built, skipping\n", name)
+				output.Playlists = append(output.Playlists, buildOutput{Name: name, Skipped: map[string]int{}, Unchanged: true})
+				continue
+			}
+			if err != nil {
+				var partialErr *api.PartialWriteError
+				if errors.As(err, &partialErr) {
+					fmt.Fprintf(os.Stderr, "The playlist %s is incomplete, these tracks are missing:\n%s\n",
+						partialErr.PlaylistID, strings.Join(partialErr.Remaining, ","))
+				}
+				return fmt.Errorf("failed to build playlist '%s': %w", name, err)
+			}
+			if result != nil {
+				printBuildResult(result)
+				delivered += result.Delivered
+				output.Playlists = append(output.Playlists, newBuildOutput(name, result))
+			}
 		}
 
+		if benchmark {
+			return writeJSON(jsonOut, newBenchmarkOutput(client.Stats(), delivered, time.Since(startedAt)))
+		}
+		if outputFormat == "json" {
+			return writeJSON(jsonOut, output)
+		}
 		return nil
 	},
 }
@@ -105,20 +347,170 @@ it will be cleared and updated with new tracks.`,
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print version information",
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("tidal-playlist v0.1.0")
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if versionJSON {
+			return printJSON(newVersionOutput())
+		}
+
+		fmt.Println("tidal-playlist v" + version.Version)
+		return nil
 	},
 }
 
+// printBuildResult prints the summary of a build.
+func printBuildResult(result *builder.BuildResult) {
+	requested := ""
+	if result.Requested > 0 {
+		requested = fmt.Sprintf(" of %d requested", result.Requested)
+	}
+	artists := ""
+	if result.UniqueArtists > 0 {
+		artists = fmt.Sprintf(" from %d artists", result.UniqueArtists)
+	}
+	fmt.Printf("\n%d%s tracks%s in %s\n", result.Delivered, requested, artists, result.Elapsed.Round(time.Second))
+}
+
+// loadConfig loads the configuration and applies the global flag overrides.
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if accessToken != "" {
+		cfg.Tidal.AccessToken = accessToken
+	}
+
+	return cfg, nil
+}
+
+// newClient creates an API client for the given configuration.
+// A supplied access token is verified with a test call before it is used,
+// unless --skip-auth-check is set.
+func newClient(ctx context.Context, cfg *config.Config) (*api.Client, error) {
+	authMgr := api.NewAuthManager(cfg.Tidal.ClientID, cfg.Tidal.ClientSecret)
+	if profile != "" {
+		authMgr.UseProfile(profile)
+	}
+	if cfg.Tidal.TokenStore == "keyring" {
+		authMgr.UseKeyring()
+	}
+	if skipAuthCheck {
+		authMgr.SkipAuthCheck()
+	}
+	client := api.NewClient(authMgr, cfg)
+
+	if cfg.Tidal.AccessToken != "" {
+		authMgr.UseToken(cfg.Tidal.AccessToken, cfg.Tidal.RefreshToken)
+		if skipAuthCheck {
+			return client, nil
+		}
+		if err := client.VerifyToken(ctx); err != nil {
+			return nil, fmt.Errorf("failed to verify access token: %w", err)
+		}
+	}
+
+	return client, nil
+}
+
+// applyArtistIDs merges the artist IDs of --include-ids and --exclude-ids into
+// the whitelist and blacklist. The flags take precedence over the config, so
+// an ID is removed from the opposite list.
+func applyArtistIDs(cfg *config.Config, include, exclude []string) error {
+	hadWhitelist := len(cfg.Filters.Whitelist) > 0
+
+	for _, id := range include {
+		cfg.Filters.Blacklist = slices.DeleteFunc(cfg.Filters.Blacklist, func(entry string) bool {
+			return strings.EqualFold(entry, id)
+		})
+		cfg.Filters.Whitelist = append(cfg.Filters.Whitelist, id)
+	}
+	for _, id := range exclude {
+		cfg.Filters.Whitelist = slices.DeleteFunc(cfg.Filters.Whitelist, func(entry string) bool {
+			return strings.EqualFold(entry, id)
+		})
+		cfg.Filters.Blacklist = append(cfg.Filters.Blacklist, id)
+	}
+
+	// An emptied whitelist would allow every artist instead of none.
+	if hadWhitelist && len(cfg.Filters.Whitelist) == 0 {
+		return fmt.Errorf("--exclude-ids removes every artist of the whitelist")
+	}
+	return nil
+}
+
+// applyCount sets either the track count or the target duration from the
+// value of the --count flag.
+func applyCount(cfg *config.Config, value string) error {
+	if n, err := strconv.Atoi(value); err == nil {
+		if n < 1 {
+			return fmt.Errorf("--count must be at least 1")
+		}
+		cfg.Playlist.Count = n
+		cfg.Playlist.TargetDuration = 0
+		return nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return fmt.Errorf("--count must be a number of tracks or a duration like 90m, got %q", value)
+	}
+	cfg.Playlist.TargetDuration = duration
+	return nil
+}
+
+// confirm asks the user a yes/no question on the terminal.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+
+	var answer string
+	fmt.Scanln(&answer)
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
 func init() {
 	// Global flags
-	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "", "", "config file (default: ./config.yaml)")
+	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "", "", "config file, - for stdin or an http(s) URL (default: ./config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&accessToken, "access-token", "", "use this access token instead of the saved one (or set TIDAL_ACCESS_TOKEN)")
+	rootCmd.PersistentFlags().BoolVar(&skipAuthCheck, "skip-auth-check", false, "trust the saved token and only refresh it once the API rejects it")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "use the saved token of this profile, e.g. to log in to a second account")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
 
 	// Create command flags
 	createCmd.Flags().StringVarP(&playlistName, "name", "n", "", "playlist name")
-	createCmd.Flags().IntVarP(&count, "count", "c", 0, "number of tracks (overrides config)")
+	createCmd.Flags().StringVar(&namePrefix, "name-prefix", "", "text to put in front of the playlist name (overrides config)")
+	createCmd.Flags().StringVar(&nameSuffix, "name-suffix", "", "text to append to the playlist name (overrides config)")
+	createCmd.Flags().StringVar(&description, "description", "", "playlist description, keeps the current one if not set (overrides config)")
+	createCmd.Flags().StringVarP(&count, "count", "c", "", "number of tracks, or total duration like 90m or 2h (overrides config)")
+	createCmd.Flags().IntVar(&minTracks, "min-tracks", 0, "fail instead of writing a playlist with fewer tracks (overrides config)")
+	createCmd.Flags().IntVar(&maxAlbums, "max-albums-fetched", 0, "stop scanning artists after fetching this many albums (overrides config)")
+	createCmd.Flags().DurationVar(&deadline, "deadline", 0, "stop collecting in time to write the playlist within this duration, e.g. 10m")
+	createCmd.Flags().BoolVar(&benchmark, "benchmark", false, "print a JSON report about the API efficiency of the run")
+	createCmd.Flags().MarkHidden("benchmark")
 	createCmd.Flags().BoolVar(&dryRun, "dry-run", false, "preview what would be created without making changes")
+	createCmd.Flags().BoolVar(&commit, "commit", false, "write the playlist even if safety.dry_run_by_default is set")
+	createCmd.Flags().BoolVar(&commit, "no-dry-run", false, "alias for --commit")
+	createCmd.Flags().BoolVar(&cleanup, "cleanup-on-failure", false, "delete the new playlist again if adding its tracks fails")
+	createCmd.Flags().IntVar(&addRetries, "add-retries", 0, "retry adding the remaining tracks this many times if it fails (overrides config)")
+	createCmd.Flags().BoolVar(&ifChanged, "if-changed", false, "skip the build if your favorite artists didn't change since the last run")
+	createCmd.Flags().BoolVar(&explainSkips, "explain-skips", false, "print everything rejected by a filter and why")
+	createCmd.Flags().Int64Var(&seed, "seed", 0, "seed for the random selection, to reproduce a previous run (overrides config)")
+	createCmd.Flags().StringSliceVar(&includeIDs, "include-ids", nil, "comma-separated artist IDs to add to the whitelist")
+	createCmd.Flags().StringSliceVar(&excludeIDs, "exclude-ids", nil, "comma-separated artist IDs to add to the blacklist")
+	createCmd.Flags().BoolVar(&appendMode, "append", false, "append the tracks to an existing playlist of the same name instead of replacing it (playlist.mode: append)")
+	createCmd.Flags().BoolVar(&retryFailed, "retry-failed", false, "only try the artists again that contributed no tracks to the last build, appending their tracks")
+	createCmd.Flags().BoolVar(&appendFavs, "append-favorites", false, "only append tracks of artists added to your favorites since the last run")
+	createCmd.Flags().BoolVar(&estimate, "estimate", false, "only estimate the API requests and runtime of the build, without collecting tracks")
+	createCmd.Flags().StringVar(&exportPath, "export", "", "also export the playlist as M3U8 file to this path, works with --dry-run")
+	createCmd.Flags().StringVar(&outputFormat, "output", "text", "output format of the result: text or json, with json the progress goes to stderr")
+	createCmd.Flags().StringVar(&deferWrite, "defer-write", "", "add the playlist to this queue file instead of writing it, see flush-queue")
+	createCmd.Flags().StringVar(&saveRecipe, "save-recipe", "", "save the selected tracks, seed and filters to this file")
+	createCmd.Flags().StringVar(&fromRecipe, "from-recipe", "", "write exactly the tracks of a saved recipe instead of selecting new ones")
+	createCmd.Flags().StringSliceVar(&profiles, "profiles", nil, "comma-separated profiles whose favorite artists are merged (overrides config)")
+	createCmd.Flags().BoolVar(&alsoLike, "also-like", false, "also add every track of the playlist to your favorite tracks")
+
+	versionCmd.Flags().BoolVar(&versionJSON, "json", false, "print the version information as JSON")
 
 	// Add commands
 	rootCmd.AddCommand(authCmd)
@@ -127,8 +519,18 @@ func init() {
 }
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	// Cancel everything in progress on Ctrl+C or when stopped, e.g. by a
+	// container runtime.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			fmt.Fprintf(os.Stderr, "\nInterrupted: %v\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
 		os.Exit(1)
 	}
 }
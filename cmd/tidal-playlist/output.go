@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/aligator/tidal-playlist/internal/api"
+	"github.com/aligator/tidal-playlist/internal/builder"
+	"github.com/aligator/tidal-playlist/internal/models"
+	"github.com/aligator/tidal-playlist/internal/version"
+)
+
+// jsonSchemaVersion is part of every JSON output. It is increased whenever
+// fields are renamed or removed, adding fields keeps the version.
+const jsonSchemaVersion = 1
+
+// versionOutput is the JSON output of the version command.
+type versionOutput struct {
+	SchemaVersion int    `json:"schema_version"`
+	Version       string `json:"version"`
+	GoVersion     string `json:"go_version"`
+}
+
+// artistsOutput is the JSON output of the artists command.
+type artistsOutput struct {
+	SchemaVersion int           `json:"schema_version"`
+	Artists       []artistEntry `json:"artists"`
+}
+
+// artistEntry is a favorite artist as printed by the artists command.
+type artistEntry struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// playlistsOutput is the JSON output of the list command.
+type playlistsOutput struct {
+	SchemaVersion int             `json:"schema_version"`
+	Playlists     []playlistEntry `json:"playlists"`
+}
+
+// playlistEntry is a playlist as printed by the list command. The field
+// names are the ones of the first schema version, which marshaled the model
+// directly.
+type playlistEntry struct {
+	ID             string       `json:"id"`
+	UUID           string       `json:"uuid"`
+	Title          string       `json:"title"`
+	Name           string       `json:"name"`
+	Description    string       `json:"description,omitempty"`
+	Creator        creatorEntry `json:"creator"`
+	Created        time.Time    `json:"created"`
+	LastUpdated    time.Time    `json:"lastUpdated"`
+	NumberOfTracks int          `json:"numberOfTracks,omitempty"`
+	AccessType     string       `json:"accessType,omitempty"`
+	Images         []imageEntry `json:"images,omitempty"`
+}
+
+// creatorEntry is the creator of a playlist.
+type creatorEntry struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// imageEntry is one size of a cover image.
+type imageEntry struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+}
+
+// createOutput is the JSON output of create --output json.
+type createOutput struct {
+	SchemaVersion int           `json:"schema_version"`
+	Playlists     []buildOutput `json:"playlists"`
+}
+
+// buildOutput is the result of building one playlist.
+type buildOutput struct {
+	Name          string         `json:"name"`
+	PlaylistID    string         `json:"playlist_id,omitempty"` // of the first part if it was split, empty in dry-run mode
+	URL           string         `json:"url,omitempty"`
+	Requested     int            `json:"requested"` // 0 with a target duration
+	Delivered     int            `json:"delivered"`
+	UniqueArtists int            `json:"unique_artists"`
+	Skipped       map[string]int `json:"skipped"` // filter rejections per rule
+	ElapsedMS     int64          `json:"elapsed_ms"`
+	DryRun        bool           `json:"dry_run"`
+	Unchanged     bool           `json:"unchanged"` // skipped, the favorite artists didn't change
+}
+
+// validateFavoritesOutput is the JSON output of the validate-favorites command.
+type validateFavoritesOutput struct {
+	SchemaVersion int                  `json:"schema_version"`
+	Checked       int                  `json:"checked"`
+	Invalid       []invalidArtistEntry `json:"invalid"`
+}
+
+// invalidArtistEntry is a favorite artist that never contributes tracks.
+type invalidArtistEntry struct {
+	ID      string `json:"id"`
+	Name    string `json:"name,omitempty"` // empty if the artist wasn't found
+	Problem string `json:"problem"`        // not_found or no_albums
+}
+
+// benchmarkOutput is the report of create --benchmark.
+type benchmarkOutput struct {
+	SchemaVersion    int     `json:"schema_version"`
+	Requests         int     `json:"requests"`
+	TracksDelivered  int     `json:"tracks_delivered"`
+	RequestsPerTrack float64 `json:"requests_per_track"`
+	AvgLatencyMS     int64   `json:"avg_latency_ms"`
+	WallTimeMS       int64   `json:"wall_time_ms"`
+}
+
+// newVersionOutput returns the version information for JSON output.
+func newVersionOutput() versionOutput {
+	return versionOutput{
+		SchemaVersion: jsonSchemaVersion,
+		Version:       version.Version,
+		GoVersion:     runtime.Version(),
+	}
+}
+
+// newPlaylistsOutput returns the playlists for JSON output.
+func newPlaylistsOutput(playlists []models.Playlist) playlistsOutput {
+	output := playlistsOutput{
+		SchemaVersion: jsonSchemaVersion,
+		Playlists:     make([]playlistEntry, len(playlists)),
+	}
+	for i, playlist := range playlists {
+		entry := playlistEntry{
+			ID:             playlist.ID,
+			UUID:           playlist.UUID,
+			Title:          playlist.Title,
+			Name:           playlist.Name,
+			Description:    playlist.Description,
+			Creator:        creatorEntry{ID: playlist.Creator.ID, Name: playlist.Creator.Name},
+			Created:        playlist.Created,
+			LastUpdated:    playlist.LastUpdated,
+			NumberOfTracks: playlist.NumberOfTracks,
+			AccessType:     playlist.AccessType,
+		}
+		for _, image := range playlist.Images {
+			entry.Images = append(entry.Images, imageEntry{URL: image.URL, Width: image.Width, Height: image.Height})
+		}
+		output.Playlists[i] = entry
+	}
+	return output
+}
+
+// newBuildOutput returns the result of a build for JSON output.
+func newBuildOutput(name string, result *builder.BuildResult) buildOutput {
+	skipped := result.Skipped
+	if skipped == nil {
+		skipped = map[string]int{}
+	}
+	return buildOutput{
+		Name:          name,
+		PlaylistID:    result.PlaylistID,
+		URL:           result.URL,
+		Requested:     result.Requested,
+		Delivered:     result.Delivered,
+		UniqueArtists: result.UniqueArtists,
+		Skipped:       skipped,
+		ElapsedMS:     result.Elapsed.Milliseconds(),
+		DryRun:        result.DryRun,
+	}
+}
+
+// newBenchmarkOutput returns the benchmark report of a run.
+func newBenchmarkOutput(stats api.RequestStats, delivered int, wallTime time.Duration) benchmarkOutput {
+	output := benchmarkOutput{
+		SchemaVersion:   jsonSchemaVersion,
+		Requests:        stats.Requests,
+		TracksDelivered: delivered,
+		AvgLatencyMS:    stats.AverageLatency.Milliseconds(),
+		WallTimeMS:      wallTime.Milliseconds(),
+	}
+	if delivered > 0 {
+		output.RequestsPerTrack = float64(stats.Requests) / float64(delivered)
+	}
+	return output
+}
+
+// printJSON writes v as indented JSON to stdout.
+func printJSON(v any) error {
+	return writeJSON(os.Stdout, v)
+}
+
+// writeJSON writes v as indented JSON to w.
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// progressToStderr sends everything printed to stdout from now on to stderr
+// instead, so that a JSON document written to the returned original stdout
+// isn't mixed with progress output.
+func progressToStderr() *os.File {
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	return stdout
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aligator/tidal-playlist/internal/builder"
+	"github.com/aligator/tidal-playlist/internal/models"
+)
+
+// marshalKeys marshals v and returns the keys of the resulting object.
+func marshalKeys(t *testing.T, v any) map[string]any {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, v); err != nil {
+		t.Fatalf("writeJSON: %v", err)
+	}
+	var object map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &object); err != nil {
+		t.Fatalf("unmarshal %s: %v", buf.String(), err)
+	}
+	return object
+}
+
+func keysOf(object map[string]any) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestPlaylistsOutputJSON(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	output := newPlaylistsOutput([]models.Playlist{{
+		ID:             "p1",
+		UUID:           "u1",
+		Title:          "Daily Mix",
+		Name:           "Daily Mix",
+		Description:    "built by tidal-playlist",
+		Creator:        models.Creator{ID: "42", Name: "me"},
+		Created:        created,
+		LastUpdated:    created,
+		NumberOfTracks: 50,
+		AccessType:     "UNLISTED",
+		Images:         []models.Image{{URL: "https://example.com/a.jpg", Width: 640, Height: 640}},
+	}})
+
+	object := marshalKeys(t, output)
+	if object["schema_version"] != float64(jsonSchemaVersion) {
+		t.Errorf("schema_version = %v, want %d", object["schema_version"], jsonSchemaVersion)
+	}
+	playlists := object["playlists"].([]any)
+	if len(playlists) != 1 {
+		t.Fatalf("got %d playlists, want 1", len(playlists))
+	}
+	playlist := playlists[0].(map[string]any)
+	wantKeys := []string{"accessType", "created", "creator", "description", "id", "images", "lastUpdated", "name", "numberOfTracks", "title", "uuid"}
+	if keys := keysOf(playlist); !reflect.DeepEqual(keys, wantKeys) {
+		t.Errorf("playlist keys = %v, want %v", keys, wantKeys)
+	}
+	if playlist["created"] != "2026-01-02T03:04:05Z" {
+		t.Errorf("created = %v", playlist["created"])
+	}
+	creator := playlist["creator"].(map[string]any)
+	if creator["id"] != "42" || creator["name"] != "me" {
+		t.Errorf("creator = %v", creator)
+	}
+	image := playlist["images"].([]any)[0].(map[string]any)
+	if image["url"] != "https://example.com/a.jpg" || image["width"] != float64(640) {
+		t.Errorf("image = %v", image)
+	}
+}
+
+func TestPlaylistsOutputJSONEmpty(t *testing.T) {
+	object := marshalKeys(t, newPlaylistsOutput(nil))
+	playlists, ok := object["playlists"].([]any)
+	if !ok || len(playlists) != 0 {
+		t.Errorf("playlists = %v, want an empty array", object["playlists"])
+	}
+}
+
+func TestCreateOutputJSON(t *testing.T) {
+	output := createOutput{
+		SchemaVersion: jsonSchemaVersion,
+		Playlists: []buildOutput{
+			newBuildOutput("Daily Mix", &builder.BuildResult{
+				PlaylistID:    "p1",
+				URL:           "https://tidal.com/browse/playlist/p1",
+				Requested:     50,
+				Delivered:     48,
+				UniqueArtists: 40,
+				Skipped:       map[string]int{"blacklist": 3},
+				Elapsed:       1500 * time.Millisecond,
+			}),
+			newBuildOutput("Preview", &builder.BuildResult{Requested: 10, Delivered: 10, DryRun: true}),
+		},
+	}
+
+	object := marshalKeys(t, output)
+	playlists := object["playlists"].([]any)
+	if len(playlists) != 2 {
+		t.Fatalf("got %d playlists, want 2", len(playlists))
+	}
+
+	written := playlists[0].(map[string]any)
+	wantKeys := []string{"delivered", "dry_run", "elapsed_ms", "name", "playlist_id", "requested", "skipped", "unchanged", "unique_artists", "url"}
+	if keys := keysOf(written); !reflect.DeepEqual(keys, wantKeys) {
+		t.Errorf("keys = %v, want %v", keys, wantKeys)
+	}
+	if written["elapsed_ms"] != float64(1500) {
+		t.Errorf("elapsed_ms = %v, want 1500", written["elapsed_ms"])
+	}
+	if skipped := written["skipped"].(map[string]any); skipped["blacklist"] != float64(3) {
+		t.Errorf("skipped = %v", skipped)
+	}
+
+	// A dry run has no playlist ID or URL, but always an object for skipped.
+	dryRun := playlists[1].(map[string]any)
+	if _, ok := dryRun["playlist_id"]; ok {
+		t.Errorf("dry run has a playlist_id: %v", dryRun)
+	}
+	if _, ok := dryRun["url"]; ok {
+		t.Errorf("dry run has a url: %v", dryRun)
+	}
+	if skipped, ok := dryRun["skipped"].(map[string]any); !ok || len(skipped) != 0 {
+		t.Errorf("skipped = %v, want an empty object", dryRun["skipped"])
+	}
+	if dryRun["dry_run"] != true {
+		t.Errorf("dry_run = %v, want true", dryRun["dry_run"])
+	}
+}
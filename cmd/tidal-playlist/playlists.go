@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aligator/tidal-playlist/internal/api"
+	"github.com/aligator/tidal-playlist/internal/models"
+)
+
+// findPlaylist finds the single playlist with the given name. With fuzzy set
+// and no exact match, similar playlists are offered one by one and only the
+// one the user confirms is returned.
+func findPlaylist(ctx context.Context, client *api.Client, name string, fuzzy bool) (*models.Playlist, error) {
+	playlists, err := client.FindAllPlaylistsByName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find playlist: %w", err)
+	}
+	if len(playlists) > 1 {
+		return nil, fmt.Errorf("found %d playlists named '%s', refusing to pick one of them", len(playlists), name)
+	}
+	if len(playlists) == 1 {
+		return &playlists[0], nil
+	}
+	if !fuzzy {
+		return nil, fmt.Errorf("no playlist named '%s' found", name)
+	}
+
+	candidates, err := client.FindPlaylistsFuzzy(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find playlist: %w", err)
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no playlist named like '%s' found", name)
+	}
+
+	fmt.Printf("No playlist named exactly '%s', similar playlists:\n", name)
+	for _, candidate := range candidates {
+		fmt.Printf("  %s (%s, %d tracks)\n", candidate.GetTitle(), candidate.GetID(), candidate.NumberOfTracks)
+	}
+
+	for _, candidate := range candidates {
+		if confirm(fmt.Sprintf("Use '%s'?", candidate.GetTitle())) {
+			return &candidate, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no playlist selected")
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/aligator/tidal-playlist/internal/builder"
+	"github.com/spf13/cobra"
+)
+
+var previewArtistCmd = &cobra.Command{
+	Use:   "preview-artist <artist-id-or-name>",
+	Short: "Show which tracks of an artist survive the filters",
+	Long: `Run one artist through the configured filters and list its albums and
+tracks the way a build sees them, together with the rule and reason for
+everything that gets filtered. Useful to find out why an artist contributes
+no tracks, without running a whole build.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+
+		ctx := cmd.Context()
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		artistID, err := client.ResolveArtistID(ctx, args[0])
+		if err != nil {
+			return err
+		}
+
+		preview, err := builder.NewBuilder(client, cfg).PreviewArtist(ctx, artistID)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s (%s)\n", preview.Artist.Attributes.Name, preview.Artist.ID)
+		if preview.Rejected != nil {
+			fmt.Printf("✗ Artist filtered %s\n", describeRejection(preview.Rejected))
+		}
+		fmt.Printf("Track strategy: %s\n", preview.TrackStrategy)
+		if preview.Relaxed {
+			fmt.Println("Note: the album filters leave nothing for this artist, they are relaxed")
+		}
+
+		if preview.TrackStrategy == "artist_top" {
+			fmt.Printf("\nTop tracks (%d):\n", len(preview.TopTracks))
+			printTrackPreviews(preview.TopTracks)
+		} else {
+			fmt.Printf("\nAlbums (%d):\n", len(preview.Albums))
+			for _, album := range preview.Albums {
+				if album.Rejected != nil {
+					fmt.Printf("  ✗ %s (%s) %s\n", album.Album.DisplayTitle(), album.Album.ID, describeRejection(album.Rejected))
+					continue
+				}
+				fmt.Printf("  ✓ %s (%s)\n", album.Album.DisplayTitle(), album.Album.ID)
+				printTrackPreviews(album.Tracks)
+			}
+		}
+
+		candidates := preview.Candidates()
+		switch {
+		case preview.Rejected != nil:
+			fmt.Println("\nThe artist itself is filtered, so it contributes no tracks")
+		case candidates == 0:
+			fmt.Println("\nNo tracks survive the filters, so the artist contributes no tracks")
+		default:
+			fmt.Printf("\n%d candidate tracks survive the filters\n", candidates)
+		}
+		return nil
+	},
+}
+
+// printTrackPreviews prints the tracks of a preview with the reasons for
+// the filtered ones.
+func printTrackPreviews(tracks []builder.TrackPreview) {
+	for _, track := range tracks {
+		if track.Rejected != nil {
+			fmt.Printf("      ✗ %s %s\n", track.Track.Title, describeRejection(track.Rejected))
+		} else {
+			fmt.Printf("      ✓ %s\n", track.Track.Title)
+		}
+	}
+}
+
+// describeRejection returns the rule and reason of a rejection for output.
+func describeRejection(rejection *builder.Rejection) string {
+	if rejection.Reason == "" {
+		return "by " + rejection.Rule
+	}
+	return fmt.Sprintf("by %s (%s)", rejection.Rule, rejection.Reason)
+}
+
+func init() {
+	rootCmd.AddCommand(previewArtistCmd)
+}
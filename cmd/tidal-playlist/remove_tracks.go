@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/aligator/tidal-playlist/internal/models"
+	"github.com/spf13/cobra"
+)
+
+var (
+	removeIndexes  []int
+	removeExplicit bool
+	removeDryRun   bool
+	removeFuzzy    bool
+)
+
+var removeTracksCmd = &cobra.Command{
+	Use:   "remove-tracks <playlist-name> [track-id...]",
+	Short: "Remove tracks from a playlist",
+	Long: `Remove tracks from an existing playlist without regenerating it.
+
+Tracks can be given by their ID (every occurrence is removed), by their
+1-based position in the playlist with --index, or all explicit tracks can be
+removed with --explicit.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		trackIDs := args[1:]
+		if len(trackIDs) == 0 && len(removeIndexes) == 0 && !removeExplicit {
+			return fmt.Errorf("nothing to remove, pass track IDs, --index or --explicit")
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+
+		ctx := cmd.Context()
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		playlist, err := findPlaylist(ctx, client, args[0], removeFuzzy)
+		if err != nil {
+			return err
+		}
+		playlistID := playlist.GetID()
+
+		items, err := client.GetPlaylistItems(ctx, playlistID)
+		if err != nil {
+			return err
+		}
+
+		for _, index := range removeIndexes {
+			if index < 1 || index > len(items) {
+				return fmt.Errorf("index %d is out of range, the playlist has %d tracks", index, len(items))
+			}
+		}
+
+		explicit := make(map[string]bool)
+		if removeExplicit {
+			ids := make([]string, len(items))
+			for i, item := range items {
+				ids[i] = item.ID
+			}
+			tracks, err := client.GetTracks(ctx, ids)
+			if err != nil {
+				return err
+			}
+			for _, track := range tracks {
+				if track.Explicit {
+					explicit[track.ID] = true
+				}
+			}
+		}
+
+		var remove []models.PlaylistItem
+		for i, item := range items {
+			if slices.Contains(removeIndexes, i+1) || slices.Contains(trackIDs, item.ID) || explicit[item.ID] {
+				remove = append(remove, item)
+			}
+		}
+
+		if len(remove) == 0 {
+			fmt.Println("No matching tracks found, nothing to remove")
+			return nil
+		}
+
+		if removeDryRun {
+			fmt.Printf("Would remove %d tracks from '%s':\n", len(remove), playlist.GetTitle())
+			for _, item := range remove {
+				fmt.Printf("  %s\n", item.ID)
+			}
+			return nil
+		}
+
+		if err := client.RemovePlaylistEntries(ctx, playlistID, remove); err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Removed %d tracks from '%s'\n", len(remove), playlist.GetTitle())
+		return nil
+	},
+}
+
+func init() {
+	removeTracksCmd.Flags().IntSliceVar(&removeIndexes, "index", nil, "1-based position of a track to remove, may be repeated")
+	removeTracksCmd.Flags().BoolVar(&removeExplicit, "explicit", false, "remove all explicit tracks")
+	removeTracksCmd.Flags().BoolVar(&removeDryRun, "dry-run", false, "only show which tracks would be removed")
+
+	removeTracksCmd.Flags().BoolVar(&removeFuzzy, "fuzzy", false, "offer similarly named playlists if there is no exact match")
+
+	rootCmd.AddCommand(removeTracksCmd)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aligator/tidal-playlist/internal/api"
+	"github.com/aligator/tidal-playlist/internal/backup"
+	"github.com/spf13/cobra"
+)
+
+var (
+	restoreSkipExisting bool
+	restoreDryRun       bool
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <dir>",
+	Short: "Recreate playlists from a backup",
+	Long: `Recreate every playlist backed up with the backup command, with its name,
+description and tracks. The playlists are always created as new playlists,
+use --skip-existing to leave out playlists whose name already exists.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		playlists, err := backup.ReadDir(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to read backup: %w", err)
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+
+		ctx := cmd.Context()
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		existing := make(map[string]bool)
+		if restoreSkipExisting {
+			current, err := client.GetUserPlaylists(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to fetch playlists: %w", err)
+			}
+			for _, playlist := range current {
+				existing[playlist.GetTitle()] = true
+			}
+		}
+
+		restored, incomplete := 0, 0
+		for _, playlist := range playlists {
+			if existing[playlist.Name] {
+				fmt.Printf("Skipping '%s', it already exists\n", playlist.Name)
+				continue
+			}
+
+			trackIDs := make([]string, len(playlist.Tracks))
+			for i, track := range playlist.Tracks {
+				trackIDs[i] = track.ID
+			}
+
+			if restoreDryRun {
+				fmt.Printf("Would restore '%s' with %d tracks\n", playlist.Name, len(trackIDs))
+				continue
+			}
+
+			fmt.Printf("Restoring '%s' with %d tracks...\n", playlist.Name, len(trackIDs))
+			created, err := client.CreatePlaylist(ctx, playlist.Name, playlist.Description)
+			if err != nil {
+				return fmt.Errorf("failed to restore '%s': %w", playlist.Name, err)
+			}
+
+			if err := client.AddTracksToPlaylist(ctx, created.GetID(), trackIDs); err != nil {
+				var partialErr *api.PartialWriteError
+				if !errors.As(err, &partialErr) {
+					return fmt.Errorf("failed to restore '%s': %w", playlist.Name, err)
+				}
+
+				fmt.Printf("Warning: failed to add %d tracks to '%s': %v\n  %s\n",
+					len(partialErr.Remaining), playlist.Name, partialErr.Err, strings.Join(partialErr.Remaining, ","))
+				incomplete++
+			}
+			restored++
+		}
+
+		if restoreDryRun {
+			return nil
+		}
+
+		fmt.Printf("\n✓ Restored %d playlists", restored)
+		if incomplete > 0 {
+			fmt.Printf(", %d of them are incomplete", incomplete)
+		}
+		fmt.Println()
+		return nil
+	},
+}
+
+func init() {
+	restoreCmd.Flags().BoolVar(&restoreSkipExisting, "skip-existing", false, "don't restore playlists whose name already exists")
+	restoreCmd.Flags().BoolVar(&restoreDryRun, "dry-run", false, "only show which playlists would be restored")
+
+	rootCmd.AddCommand(restoreCmd)
+}
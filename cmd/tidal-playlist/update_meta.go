@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	metaName        string
+	metaDescription string
+	metaFuzzy       bool
+)
+
+var updateMetaCmd = &cobra.Command{
+	Use:   "update-meta <playlist-name>",
+	Short: "Update the name or description of a playlist",
+	Long: `Update the name and/or description of an existing playlist in place,
+without touching its tracks.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if metaName == "" && metaDescription == "" {
+			return fmt.Errorf("nothing to update, pass --name and/or --description")
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+
+		ctx := cmd.Context()
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		playlist, err := findPlaylist(ctx, client, args[0], metaFuzzy)
+		if err != nil {
+			return err
+		}
+
+		if err := client.UpdatePlaylistMetadata(ctx, playlist.GetID(), metaName, metaDescription); err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Updated playlist '%s'\n", playlist.GetTitle())
+		return nil
+	},
+}
+
+func init() {
+	updateMetaCmd.Flags().StringVar(&metaName, "name", "", "new playlist name")
+	updateMetaCmd.Flags().StringVar(&metaDescription, "description", "", "new playlist description")
+
+	updateMetaCmd.Flags().BoolVar(&metaFuzzy, "fuzzy", false, "offer similarly named playlists if there is no exact match")
+
+	rootCmd.AddCommand(updateMetaCmd)
+}
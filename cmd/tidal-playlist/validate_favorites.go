@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"text/tabwriter"
+
+	"github.com/aligator/tidal-playlist/internal/api"
+	"github.com/spf13/cobra"
+)
+
+var (
+	validateFavoritesJSON bool
+	validateFavoritesFix  bool
+)
+
+// Problems reported by validate-favorites.
+const (
+	problemNotFound = "not_found"
+	problemNoAlbums = "no_albums"
+)
+
+var validateFavoritesCmd = &cobra.Command{
+	Use:   "validate-favorites",
+	Short: "Check that all favorite artists can be used",
+	Long: `Check every favorite artist and report those that can't be found anymore
+or have no albums available in your country. These artists never contribute
+tracks, so they are good candidates for the blacklist.
+
+With --fix you are asked for each of them whether to blacklist it, and the
+chosen IDs are printed ready to paste into filters.blacklist.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if validateFavoritesJSON && validateFavoritesFix {
+			return fmt.Errorf("--fix can't be combined with --json")
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+
+		ctx := cmd.Context()
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		favorites, err := client.GetFavoriteArtists(ctx)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]string, 0, len(favorites))
+		for _, favorite := range favorites {
+			ids = append(ids, favorite.ID)
+		}
+
+		// Fetch all artists in batches, only the missing ones are looked
+		// up one by one to tell them apart from other errors.
+		artists, err := client.GetArtists(ctx, ids)
+		if err != nil {
+			return err
+		}
+		names := make(map[string]string, len(artists))
+		for _, artist := range artists {
+			names[artist.ID] = artist.Attributes.Name
+		}
+
+		var invalid []invalidArtistEntry
+		for _, id := range ids {
+			name, found := names[id]
+			if !found {
+				_, err := client.GetArtist(ctx, id)
+				var apiErr *api.APIError
+				if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+					invalid = append(invalid, invalidArtistEntry{ID: id, Problem: problemNotFound})
+					continue
+				}
+				if err != nil {
+					return fmt.Errorf("failed to check artist %s: %w", id, err)
+				}
+			}
+
+			albums, err := client.GetArtistAlbums(ctx, id, 1)
+			if err != nil {
+				return fmt.Errorf("failed to check albums of artist %s: %w", id, err)
+			}
+			if len(albums) == 0 {
+				invalid = append(invalid, invalidArtistEntry{ID: id, Name: name, Problem: problemNoAlbums})
+			}
+		}
+
+		if validateFavoritesJSON {
+			return printJSON(validateFavoritesOutput{
+				SchemaVersion: jsonSchemaVersion,
+				Checked:       len(ids),
+				Invalid:       invalid,
+			})
+		}
+
+		if len(invalid) == 0 {
+			fmt.Printf("✓ All %d favorite artists are fine\n", len(ids))
+			return nil
+		}
+
+		fmt.Printf("Found %d of %d favorite artists that never contribute tracks:\n", len(invalid), len(ids))
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tPROBLEM")
+		for _, entry := range invalid {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", entry.ID, entry.Name, describeProblem(entry.Problem))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		if !validateFavoritesFix {
+			return nil
+		}
+
+		var blacklist []invalidArtistEntry
+		for _, entry := range invalid {
+			label := entry.ID
+			if entry.Name != "" {
+				label = fmt.Sprintf("%s (%s)", entry.Name, entry.ID)
+			}
+			if confirm(fmt.Sprintf("Blacklist %s?", label)) {
+				blacklist = append(blacklist, entry)
+			}
+		}
+
+		if len(blacklist) == 0 {
+			fmt.Println("Nothing to blacklist")
+			return nil
+		}
+
+		fmt.Println("\nAdd these entries to filters.blacklist in your config:")
+		fmt.Println("  blacklist:")
+		for _, entry := range blacklist {
+			if entry.Name != "" {
+				fmt.Printf("    - %q # %s\n", entry.ID, entry.Name)
+			} else {
+				fmt.Printf("    - %q\n", entry.ID)
+			}
+		}
+		return nil
+	},
+}
+
+// describeProblem returns a readable description of a validate-favorites
+// problem.
+func describeProblem(problem string) string {
+	switch problem {
+	case problemNotFound:
+		return "artist not found"
+	case problemNoAlbums:
+		return "no albums available"
+	}
+	return problem
+}
+
+func init() {
+	validateFavoritesCmd.Flags().BoolVar(&validateFavoritesJSON, "json", false, "print the result as JSON")
+	validateFavoritesCmd.Flags().BoolVar(&validateFavoritesFix, "fix", false, "ask which of the reported artists to blacklist")
+
+	rootCmd.AddCommand(validateFavoritesCmd)
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/aligator/tidal-playlist/internal/models"
+)
+
+// albumResource is the JSON:API representation of an album. Included
+// artists are decoded into the same type, using the name attribute.
+type albumResource struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	Attributes struct {
+		Title       string        `json:"title"`
+		Name        string        `json:"name"`
+		AlbumType   string        `json:"type"`
+		ReleaseDate string        `json:"releaseDate"`
+		Copyright   copyrightText `json:"copyright"`
+		Explicit    bool          `json:"explicit"`
+		Items       int           `json:"numberOfItems"`
+		ImageLinks  []imageLink   `json:"imageLinks"`
+	} `json:"attributes"`
+	Relationships struct {
+		Artists struct {
+			Data []models.ArtistID `json:"data"`
+		} `json:"artists"`
+	} `json:"relationships"`
+}
+
+// toModel converts the resource into an album. Without relationship data the
+// album is assumed to be by the fallback artist. Artist names are taken from
+// artistNames where known.
+func (r albumResource) toModel(fallbackArtistID string, artistNames map[string]string) models.Album {
+	artistIDs := r.Relationships.Artists.Data
+	if len(artistIDs) == 0 && fallbackArtistID != "" {
+		artistIDs = []models.ArtistID{{ID: fallbackArtistID}}
+	}
+
+	artists := make([]models.Artist, len(artistIDs))
+	for i, artistID := range artistIDs {
+		artists[i].ID = artistID.ID
+		artists[i].Attributes.Name = artistNames[artistID.ID]
+	}
+
+	return models.Album{
+		ID:          r.ID,
+		Title:       r.Attributes.Title,
+		Type:        r.Attributes.AlbumType,
+		Artists:     artists,
+		ReleaseDate: r.Attributes.ReleaseDate,
+		Copyright:   string(r.Attributes.Copyright),
+		Explicit:    r.Attributes.Explicit,
+
+		NumberOfTracks: r.Attributes.Items,
+		Images:         toImages(r.Attributes.ImageLinks),
+	}
+}
+
+// imageLink is an image in one of the sizes the API offers.
+type imageLink struct {
+	Href string `json:"href"`
+	Meta struct {
+		Width  int `json:"width"`
+		Height int `json:"height"`
+	} `json:"meta"`
+}
+
+// toImages converts the image links into images.
+func toImages(links []imageLink) []models.Image {
+	images := make([]models.Image, 0, len(links))
+	for _, link := range links {
+		images = append(images, models.Image{
+			URL:    link.Href,
+			Width:  link.Meta.Width,
+			Height: link.Meta.Height,
+		})
+	}
+	return images
+}
+
+// GetAlbums retrieves the metadata of several albums at once, including
+// their track counts and release dates. Albums that cannot be found are
+// missing from the result.
+func (c *Client) GetAlbums(ctx context.Context, albumIDs []string) ([]models.Album, error) {
+	albums := make([]models.Album, 0, len(albumIDs))
+	for i := 0; i < len(albumIDs); i += MaxItemsPerRequest {
+		end := min(i+MaxItemsPerRequest, len(albumIDs))
+
+		query := url.Values{}
+		query.Set("countryCode", c.config.Tidal.CountryCode)
+		query.Set("include", "artists")
+		for _, albumID := range albumIDs[i:end] {
+			query.Add("filter[id]", albumID)
+		}
+
+		resp, err := c.get(ctx, "/v2/albums?"+query.Encode())
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch albums: %w", err)
+		}
+
+		var apiResp struct {
+			Data     []albumResource `json:"data"`
+			Included []albumResource `json:"included"`
+		}
+		if err := decodeResponse(resp, &apiResp); err != nil {
+			return nil, err
+		}
+
+		artistNames := make(map[string]string)
+		for _, item := range apiResp.Included {
+			if item.Type == "artists" {
+				artistNames[item.ID] = item.Attributes.Name
+			}
+		}
+
+		for _, item := range apiResp.Data {
+			albums = append(albums, item.toModel("", artistNames))
+		}
+	}
+
+	return albums, nil
+}
+
+// copyrightText is the copyright line of an album. The API returns it either
+// as plain string or as object with a text field.
+type copyrightText string
+
+func (c *copyrightText) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*c = copyrightText(text)
+		return nil
+	}
+
+	var obj struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	*c = copyrightText(obj.Text)
+	return nil
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestGetArtistAlbumsUntitled(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/artists/7" {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, "testdata/untitled_albums.json")
+	}))
+	client.config.Tidal.AlbumFetchStrategy = "include"
+
+	albums, err := client.GetArtistAlbums(context.Background(), "7", 100)
+	if err != nil {
+		t.Fatalf("GetArtistAlbums failed: %v", err)
+	}
+
+	want := []struct {
+		id, title, displayTitle string
+	}{
+		{"501", "Greatest Hits", "Greatest Hits"},
+		{"502", "", "(untitled 502)"},
+		{"503", "", "(untitled 503)"},
+	}
+	if len(albums) != len(want) {
+		t.Fatalf("got %d albums, want %d", len(albums), len(want))
+	}
+	for i, w := range want {
+		album := albums[i]
+		if album.ID != w.id {
+			t.Errorf("album %d has ID %s, want %s", i, album.ID, w.id)
+		}
+		if album.Title != w.title {
+			t.Errorf("album %s has title %q, want %q", album.ID, album.Title, w.title)
+		}
+		if got := album.DisplayTitle(); got != w.displayTitle {
+			t.Errorf("album %s is displayed as %q, want %q", album.ID, got, w.displayTitle)
+		}
+	}
+}
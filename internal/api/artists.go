@@ -4,7 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/aligator/tidal-playlist/internal/models"
 )
@@ -18,6 +22,18 @@ func (c *Client) GetFavoriteArtists(ctx context.Context) ([]models.ArtistID, err
 
 	var allArtists []models.ArtistID
 	cursor := ""
+	total := 0
+
+	// Continue where an interrupted run stopped.
+	if progress := loadFavoritesProgress(userID); progress != nil {
+		fmt.Fprintf(os.Stderr, "Resuming favorites from a previous run (%d artists already fetched)\n", len(progress.Artists))
+		allArtists = progress.Artists
+		cursor = progress.Cursor
+		total = progress.Total
+	}
+
+	// The cursors are opaque, so the pages can only be fetched one after
+	// another.
 
 	for {
 		endpoint := fmt.Sprintf("/v2/userCollections/%s/relationships/artists", userID)
@@ -29,16 +45,18 @@ func (c *Client) GetFavoriteArtists(ctx context.Context) ([]models.ArtistID, err
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch favorite artists: %w", err)
 		}
-		defer resp.Body.Close()
-
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read response body: %w", err)
-		}
 
 		// Parse response
 		var apiResp struct {
-			Data  []models.ArtistID `json:"data"`
+			Data []struct {
+				ID   string `json:"id"`
+				Meta struct {
+					Roles []string `json:"roles"`
+				} `json:"meta"`
+			} `json:"data"`
+			Meta struct {
+				Total int `json:"total"`
+			} `json:"meta"`
 			Links struct {
 				Meta struct {
 					NextCursor string `json:"nextCursor"`
@@ -46,11 +64,24 @@ func (c *Client) GetFavoriteArtists(ctx context.Context) ([]models.ArtistID, err
 			} `json:"links"`
 		}
 
-		if err := json.Unmarshal(body, &apiResp); err != nil {
-			return nil, fmt.Errorf("failed to parse response: %w", err)
+		if err := decodeResponse(resp, &apiResp); err != nil {
+			return nil, err
 		}
 
-		allArtists = append(allArtists, apiResp.Data...)
+		for _, item := range apiResp.Data {
+			allArtists = append(allArtists, models.ArtistID{
+				ID:    item.ID,
+				Roles: item.Meta.Roles,
+			})
+		}
+
+		// Only the first page is expected to carry the total.
+		if total == 0 {
+			total = apiResp.Meta.Total
+		}
+		if total > 0 {
+			fmt.Fprintf(os.Stderr, "\rFetching favorites (%d/%d)...", len(allArtists), total)
+		}
 
 		// Check if there are more pages
 		if apiResp.Links.Meta.NextCursor == "" {
@@ -58,11 +89,79 @@ func (c *Client) GetFavoriteArtists(ctx context.Context) ([]models.ArtistID, err
 		}
 
 		cursor = apiResp.Links.Meta.NextCursor
+		saveFavoritesProgress(userID, favoritesProgress{Cursor: cursor, Total: total, Artists: allArtists})
+	}
+
+	if total > 0 {
+		fmt.Fprintln(os.Stderr)
 	}
 
+	clearFavoritesProgress(userID)
 	return allArtists, nil
 }
 
+// favoritesResumeTTL is how long the progress of an interrupted favorites
+// fetch can be resumed from.
+const favoritesResumeTTL = 30 * time.Minute
+
+// favoritesProgress is the state of a favorites fetch after the last
+// successful page.
+type favoritesProgress struct {
+	Cursor  string            `json:"cursor"`
+	Total   int               `json:"total"`
+	Artists []models.ArtistID `json:"artists"`
+	SavedAt time.Time         `json:"saved_at"`
+}
+
+// favoritesProgressPath returns where the progress of the user is cached.
+func favoritesProgressPath(userID string) string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+	return filepath.Join(cacheDir, "tidal-playlist", "favorites-"+userID+".json")
+}
+
+// loadFavoritesProgress returns the saved progress of the user, or nil if
+// there is none or it is too old.
+func loadFavoritesProgress(userID string) *favoritesProgress {
+	data, err := os.ReadFile(favoritesProgressPath(userID))
+	if err != nil {
+		return nil
+	}
+
+	var progress favoritesProgress
+	if err := json.Unmarshal(data, &progress); err != nil || progress.Cursor == "" {
+		return nil
+	}
+	if time.Since(progress.SavedAt) > favoritesResumeTTL {
+		return nil
+	}
+	return &progress
+}
+
+// saveFavoritesProgress caches the progress. Failing to do so only means
+// that an interrupted fetch can't be resumed, so errors are ignored.
+func saveFavoritesProgress(userID string, progress favoritesProgress) {
+	path := favoritesProgressPath(userID)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+
+	progress.SavedAt = time.Now()
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return
+	}
+	os.WriteFile(path, data, 0600)
+}
+
+// clearFavoritesProgress removes the cached progress once all favorites
+// were fetched.
+func clearFavoritesProgress(userID string) {
+	os.Remove(favoritesProgressPath(userID))
+}
+
 // GetArtist retrieves information about a specific artist.
 func (c *Client) GetArtist(ctx context.Context, artistID string) (*models.Artist, error) {
 	endpoint := fmt.Sprintf("/v2/artists/%s?countryCode=%s", artistID, c.config.Tidal.CountryCode)
@@ -70,25 +169,68 @@ func (c *Client) GetArtist(ctx context.Context, artistID string) (*models.Artist
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch artist: %w", err)
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
 
 	var apiResp struct {
 		Data models.Artist `json:"data"`
 	}
-	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err := decodeResponse(resp, &apiResp); err != nil {
+		return nil, err
 	}
 
 	return &apiResp.Data, nil
 }
 
-// GetArtistAlbums retrieves albums for a specific artist.
+// GetArtists retrieves several artists at once. Artists that cannot be found
+// are missing from the result.
+func (c *Client) GetArtists(ctx context.Context, artistIDs []string) ([]models.Artist, error) {
+	artists := make([]models.Artist, 0, len(artistIDs))
+	for i := 0; i < len(artistIDs); i += MaxItemsPerRequest {
+		end := min(i+MaxItemsPerRequest, len(artistIDs))
+
+		query := url.Values{}
+		query.Set("countryCode", c.config.Tidal.CountryCode)
+		for _, artistID := range artistIDs[i:end] {
+			query.Add("filter[id]", artistID)
+		}
+
+		resp, err := c.get(ctx, "/v2/artists?"+query.Encode())
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch artists: %w", err)
+		}
+
+		var apiResp struct {
+			Data []models.Artist `json:"data"`
+		}
+		if err := decodeResponse(resp, &apiResp); err != nil {
+			return nil, err
+		}
+
+		artists = append(artists, apiResp.Data...)
+	}
+
+	return artists, nil
+}
+
+// GetArtistAlbums retrieves albums for a specific artist, using the
+// configured album fetch strategy.
 func (c *Client) GetArtistAlbums(ctx context.Context, artistID string, limit int) ([]models.Album, error) {
+	switch c.config.Tidal.AlbumFetchStrategy {
+	case "include":
+		return c.getArtistAlbumsIncluded(ctx, artistID, limit)
+	case "relationship":
+		return c.getArtistAlbumsRelationship(ctx, artistID, limit)
+	}
+
+	albums, err := c.getArtistAlbumsIncluded(ctx, artistID, limit)
+	if err != nil || len(albums) > 0 {
+		return albums, err
+	}
+	return c.getArtistAlbumsRelationship(ctx, artistID, limit)
+}
+
+// getArtistAlbumsIncluded retrieves the albums of an artist by including
+// them into the artist resource.
+func (c *Client) getArtistAlbumsIncluded(ctx context.Context, artistID string, limit int) ([]models.Album, error) {
 	// Use include parameter to get full album data
 	endpoint := fmt.Sprintf("/v2/artists/%s?include=albums&countryCode=%s", artistID, c.config.Tidal.CountryCode)
 
@@ -96,37 +238,89 @@ func (c *Client) GetArtistAlbums(ctx context.Context, artistID string, limit int
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch artist albums: %w", err)
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	// Parse JSON:API format with included albums. The included albums of
+	// prolific artists make up huge documents, so they are decoded one by
+	// one and only up to the limit are kept.
+	var data struct {
+		models.Artist
+		Relationships struct {
+			Albums struct {
+				Data  []models.ArtistID `json:"data"`
+				Links struct {
+					Meta struct {
+						NextCursor string `json:"nextCursor"`
+					} `json:"meta"`
+				} `json:"links"`
+			} `json:"albums"`
+		} `json:"relationships"`
+	}
+	var includedAlbums []albumResource
+	included := make(map[string]bool)
+	artistNames := make(map[string]string)
+
+	err = decodeStream(resp, func(key string, dec *json.Decoder) error {
+		switch key {
+		case "data":
+			return dec.Decode(&data)
+		case "included":
+			return decodeArray(dec, func() error {
+				var item albumResource
+				if err := dec.Decode(&item); err != nil {
+					return err
+				}
+
+				switch item.Type {
+				case "artists":
+					artistNames[item.ID] = item.Attributes.Name
+				case "albums":
+					included[item.ID] = true
+					if len(includedAlbums) < limit {
+						includedAlbums = append(includedAlbums, item)
+					}
+				}
+				return nil
+			})
+		}
+		return skipValue(dec)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, err
 	}
 
-	// Parse JSON:API format with included albums
-	var apiResp struct {
-		Included []struct {
-			ID         string `json:"id"`
-			Type       string `json:"type"`
-			Attributes struct {
-				Title string `json:"title"`
-			} `json:"attributes"`
-		} `json:"included"`
-		UnknownFields map[string]any `json:"-"`
+	// The names of all artists known from the response.
+	artistNames[data.ID] = data.Attributes.Name
+
+	// Convert included items to Album models
+	albums := make([]models.Album, 0, len(includedAlbums))
+	for _, item := range includedAlbums {
+		albums = append(albums, item.toModel(data.ID, artistNames))
 	}
-	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+
+	// Albums listed in the relationship but missing from the included data
+	// are fetched in batches, so that they can be filtered as well.
+	var missing []string
+	for _, album := range data.Relationships.Albums.Data {
+		if !included[album.ID] && len(albums)+len(missing) < limit {
+			missing = append(missing, album.ID)
+		}
+	}
+	if len(missing) > 0 {
+		fetched, err := c.GetAlbums(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		albums = append(albums, fetched...)
 	}
 
-	// Convert included items to Album models
-	albums := make([]models.Album, 0)
-	for _, item := range apiResp.Included {
-		if item.Type == "albums" {
-			albums = append(albums, models.Album{
-				ID:    item.ID,
-				Title: item.Attributes.Title,
-			})
+	// Only the first page of the relationship is included, the remaining
+	// pages are fetched from the relationship itself.
+	if cursor := data.Relationships.Albums.Links.Meta.NextCursor; cursor != "" && len(albums) < limit {
+		more, err := c.getArtistAlbumPages(ctx, artistID, cursor, limit-len(albums))
+		if err != nil {
+			return nil, err
 		}
+		albums = append(albums, more...)
 	}
 
 	// Limit to requested number
@@ -136,3 +330,187 @@ func (c *Client) GetArtistAlbums(ctx context.Context, artistID string, limit int
 
 	return albums, nil
 }
+
+// getArtistAlbumsRelationship retrieves the albums of an artist page by page
+// from the albums relationship.
+func (c *Client) getArtistAlbumsRelationship(ctx context.Context, artistID string, limit int) ([]models.Album, error) {
+	return c.getArtistAlbumPages(ctx, artistID, "", limit)
+}
+
+// getArtistAlbumPages follows the albums relationship of an artist from the
+// given cursor, or from the start without one, until limit albums are
+// collected or there are no more pages.
+func (c *Client) getArtistAlbumPages(ctx context.Context, artistID, cursor string, limit int) ([]models.Album, error) {
+	var albums []models.Album
+	for len(albums) < limit {
+		endpoint := fmt.Sprintf("/v2/artists/%s/relationships/albums?include=albums&countryCode=%s", artistID, c.config.Tidal.CountryCode)
+		if cursor != "" {
+			endpoint += fmt.Sprintf("&page[cursor]=%s", cursor)
+		}
+
+		resp, err := c.get(ctx, endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch artist albums: %w", err)
+		}
+
+		var apiResp struct {
+			Data     []models.ArtistID `json:"data"`
+			Included []albumResource   `json:"included"`
+			Links    struct {
+				Meta struct {
+					NextCursor string `json:"nextCursor"`
+				} `json:"meta"`
+			} `json:"links"`
+		}
+		if err := decodeResponse(resp, &apiResp); err != nil {
+			return nil, err
+		}
+
+		included := make(map[string]albumResource, len(apiResp.Included))
+		for _, item := range apiResp.Included {
+			if item.Type == "albums" {
+				included[item.ID] = item
+			}
+		}
+
+		// Keep the order of the relationship, fetching albums that weren't
+		// included.
+		var missing []string
+		for _, album := range apiResp.Data {
+			if item, ok := included[album.ID]; ok {
+				albums = append(albums, item.toModel(artistID, nil))
+			} else {
+				missing = append(missing, album.ID)
+			}
+		}
+		if len(missing) > 0 {
+			fetched, err := c.GetAlbums(ctx, missing)
+			if err != nil {
+				return nil, err
+			}
+			albums = append(albums, fetched...)
+		}
+
+		// Check if there are more pages
+		if apiResp.Links.Meta.NextCursor == "" {
+			break
+		}
+
+		cursor = apiResp.Links.Meta.NextCursor
+	}
+
+	// Limit to requested number
+	if len(albums) > limit {
+		albums = albums[:limit]
+	}
+
+	return albums, nil
+}
+
+// GetArtistTopTracks retrieves the most popular tracks of an artist.
+func (c *Client) GetArtistTopTracks(ctx context.Context, artistID string, limit int) ([]models.Track, error) {
+	endpoint := fmt.Sprintf("/v2/artists/%s/relationships/tracks?collapseBy=FINGERPRINT&include=tracks&countryCode=%s", artistID, c.config.Tidal.CountryCode)
+	resp, err := c.get(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch artist top tracks: %w", err)
+	}
+
+	// Parse JSON:API format with included tracks
+	var apiResp struct {
+		Data     []models.ArtistID `json:"data"`
+		Included []trackResource   `json:"included"`
+	}
+	if err := decodeResponse(resp, &apiResp); err != nil {
+		return nil, err
+	}
+
+	included := make(map[string]trackResource, len(apiResp.Included))
+	for _, item := range apiResp.Included {
+		if item.Type == "tracks" {
+			included[item.ID] = item
+		}
+	}
+
+	// The relationship is ordered by popularity.
+	tracks := make([]models.Track, 0, len(apiResp.Data))
+	for _, item := range apiResp.Data {
+		if len(tracks) >= limit {
+			break
+		}
+		track := included[item.ID].toModel(nil)
+		track.ID = item.ID
+		track.ArtistID = artistID
+		tracks = append(tracks, track)
+	}
+
+	return tracks, nil
+}
+
+// SearchArtists searches for artists by name.
+func (c *Client) SearchArtists(ctx context.Context, query string) ([]models.Artist, error) {
+	endpoint := fmt.Sprintf("/v2/searchResults/%s/relationships/artists?include=artists&countryCode=%s", url.PathEscape(query), c.config.Tidal.CountryCode)
+	resp, err := c.get(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search artists: %w", err)
+	}
+
+	var apiResp struct {
+		Data     []models.ArtistID `json:"data"`
+		Included []models.Artist   `json:"included"`
+	}
+	if err := decodeResponse(resp, &apiResp); err != nil {
+		return nil, err
+	}
+
+	// Keep the order of the search results.
+	byID := make(map[string]models.Artist, len(apiResp.Included))
+	for _, artist := range apiResp.Included {
+		byID[artist.ID] = artist
+	}
+
+	artists := make([]models.Artist, 0, len(apiResp.Data))
+	for _, artistID := range apiResp.Data {
+		if artist, ok := byID[artistID.ID]; ok {
+			artists = append(artists, artist)
+		}
+	}
+
+	return artists, nil
+}
+
+// ResolveArtistID returns the ID of an artist given either its ID or its name.
+// Names are looked up using the search, preferring an exact match.
+func (c *Client) ResolveArtistID(ctx context.Context, idOrName string) (string, error) {
+	if isNumericID(idOrName) {
+		return idOrName, nil
+	}
+
+	artists, err := c.SearchArtists(ctx, idOrName)
+	if err != nil {
+		return "", err
+	}
+	if len(artists) == 0 {
+		return "", fmt.Errorf("no artist named '%s' found", idOrName)
+	}
+
+	for _, artist := range artists {
+		if strings.EqualFold(artist.Attributes.Name, idOrName) {
+			return artist.ID, nil
+		}
+	}
+
+	return artists[0].ID, nil
+}
+
+// isNumericID reports whether s looks like a Tidal ID rather than a name.
+func isNumericID(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// albumFixturePageSize is the page size of the albums relationship of
+// newAlbumFixture.
+const albumFixturePageSize = 20
+
+// newAlbumFixture returns a handler serving an artist with the given number
+// of albums, all included into the artist resource like the API does for
+// prolific artists, and the albums relationship in pages.
+func newAlbumFixture(t testing.TB, albums int) http.Handler {
+	t.Helper()
+
+	var ids []map[string]string
+	var included []map[string]any
+	for i := range albums {
+		id := strconv.Itoa(1000 + i)
+		ids = append(ids, map[string]string{"id": id, "type": "albums"})
+		included = append(included, map[string]any{
+			"id":   id,
+			"type": "albums",
+			"attributes": map[string]any{
+				"title":         fmt.Sprintf("Album %d with a reasonably long title", i),
+				"type":          "ALBUM",
+				"releaseDate":   fmt.Sprintf("%d-05-17", 1960+i%60),
+				"copyright":     map[string]string{"text": "(P) 2001 Some Record Label"},
+				"numberOfItems": 12,
+				"imageLinks": []map[string]any{
+					{"href": "https://resources.tidal.com/images/" + id + "/640x640.jpg", "meta": map[string]int{"width": 640, "height": 640}},
+					{"href": "https://resources.tidal.com/images/" + id + "/320x320.jpg", "meta": map[string]int{"width": 320, "height": 320}},
+				},
+			},
+		})
+	}
+
+	artist, err := json.Marshal(map[string]any{
+		"data": map[string]any{
+			"id":            "1",
+			"type":          "artists",
+			"attributes":    map[string]any{"name": "Prolific Artist"},
+			"relationships": map[string]any{"albums": map[string]any{"data": ids}},
+		},
+		"included": included,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pages [][]byte
+	for start := 0; start < albums; start += albumFixturePageSize {
+		end := min(start+albumFixturePageSize, albums)
+		next := ""
+		if end < albums {
+			next = strconv.Itoa(end)
+		}
+		page, err := json.Marshal(map[string]any{
+			"data":     ids[start:end],
+			"included": included[start:end],
+			"links":    map[string]any{"meta": map[string]string{"nextCursor": next}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, page)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/artists/1":
+			w.Write(artist)
+		case r.URL.Path == "/v2/artists/1/relationships/albums":
+			start, _ := strconv.Atoi(r.URL.Query().Get("page[cursor]"))
+			w.Write(pages[start/albumFixturePageSize])
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func TestGetArtistAlbumsFixture(t *testing.T) {
+	for _, strategy := range []string{"include", "relationship", "auto"} {
+		t.Run(strategy, func(t *testing.T) {
+			client := newTestClient(t, newAlbumFixture(t, 300))
+			client.config.Tidal.AlbumFetchStrategy = strategy
+
+			albums, err := client.GetArtistAlbums(context.Background(), "1", 100)
+			if err != nil {
+				t.Fatalf("GetArtistAlbums failed: %v", err)
+			}
+			if len(albums) != 100 {
+				t.Fatalf("got %d albums, want 100", len(albums))
+			}
+			for i, album := range albums {
+				if want := strconv.Itoa(1000 + i); album.ID != want {
+					t.Fatalf("album %d has ID %s, want %s", i, album.ID, want)
+				}
+			}
+			if !strings.HasPrefix(albums[0].Title, "Album 0") {
+				t.Errorf("title = %q", albums[0].Title)
+			}
+		})
+	}
+}
+
+func BenchmarkGetArtistAlbums(b *testing.B) {
+	for _, strategy := range []string{"include", "relationship"} {
+		for _, limit := range []int{100, 300} {
+			b.Run(fmt.Sprintf("%s/limit=%d", strategy, limit), func(b *testing.B) {
+				client := newTestClient(b, newAlbumFixture(b, 300))
+				client.config.Tidal.AlbumFetchStrategy = strategy
+				ctx := context.Background()
+
+				b.ReportAllocs()
+				for b.Loop() {
+					if _, err := client.GetArtistAlbums(ctx, "1", limit); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
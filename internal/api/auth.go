@@ -7,20 +7,29 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aligator/tidal-playlist/internal/models"
+	"github.com/aligator/tidal-playlist/internal/version"
 	"golang.org/x/oauth2"
 )
 
 const (
 	authURL  = "https://login.tidal.com/authorize"
 	tokenURL = "https://auth.tidal.com/v1/oauth2/token"
+
+	// defaultCallbackPort is the port the OAuth callback is received on.
+	defaultCallbackPort = 8080
+
+	// defaultLoginTimeout is how long Login waits for the callback.
+	defaultLoginTimeout = 5 * time.Minute
 )
 
 // AuthManager handles OAuth authentication.
@@ -29,6 +38,18 @@ type AuthManager struct {
 	clientSecret string
 	config       *oauth2.Config
 	tokenFile    string
+	staticToken  *oauth2.Token // supplied from outside, bypasses the token file
+	userAgent    string
+	trustToken   bool // skip the expiry check, refresh only when rejected
+	useKeyring   bool // store the token in the OS keyring instead of the token file
+	callbackPort int  // port of the local OAuth callback server, 0 picks a free one
+
+	// loginTimeout is how long Login waits for the callback.
+	loginTimeout time.Duration
+
+	// mu guards staticToken, which is replaced by a refresh while other
+	// requests may be using it.
+	mu sync.Mutex
 }
 
 // NewAuthManager creates a new authentication manager.
@@ -40,6 +61,9 @@ func NewAuthManager(clientID, clientSecret string) *AuthManager {
 		clientID:     clientID,
 		clientSecret: clientSecret,
 		tokenFile:    tokenFile,
+		callbackPort: defaultCallbackPort,
+		loginTimeout: defaultLoginTimeout,
+		userAgent:    version.UserAgent(),
 		config: &oauth2.Config{
 			ClientID:     clientID,
 			ClientSecret: clientSecret,
@@ -47,12 +71,91 @@ func NewAuthManager(clientID, clientSecret string) *AuthManager {
 				AuthURL:  authURL,
 				TokenURL: tokenURL,
 			},
-			RedirectURL: "http://localhost:8080/callback",
+			RedirectURL: fmt.Sprintf("http://localhost:%d/callback", defaultCallbackPort),
 			Scopes:      []string{"user.read", "collection.read", "collection.write", "playlists.read", "playlists.write"},
 		},
 	}
 }
 
+// UseProfile makes the manager save and load the token of the named profile,
+// to keep the tokens of several accounts side by side.
+func (a *AuthManager) UseProfile(profile string) {
+	a.tokenFile = filepath.Join(filepath.Dir(a.tokenFile), "token-"+profile+".json")
+}
+
+// UseToken makes the manager use an already existing access token (and
+// optionally a refresh token) instead of the saved token file.
+func (a *AuthManager) UseToken(accessToken, refreshToken string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.staticToken = &oauth2.Token{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+	}
+}
+
+// SkipAuthCheck makes the manager use the saved token as is, without checking
+// whether it is about to expire. It is only refreshed once the API rejects it.
+func (a *AuthManager) SkipAuthCheck() {
+	a.trustToken = true
+}
+
+// UseKeyring makes the manager save and load the token in the OS keyring.
+// The token file is used instead whenever no keyring is available.
+func (a *AuthManager) UseKeyring() {
+	a.useKeyring = true
+}
+
+// UseCallbackPort sets the port Login receives the OAuth callback on. With
+// port 0 a free port is picked. The redirect URL follows the port used.
+func (a *AuthManager) UseCallbackPort(port int) {
+	a.callbackPort = port
+}
+
+// reauthenticate refreshes the current token after the API rejected it.
+func (a *AuthManager) reauthenticate(ctx context.Context) error {
+	if a.currentStaticToken() != nil {
+		return a.refreshStaticToken(ctx)
+	}
+
+	token, err := a.readToken()
+	if err != nil {
+		return fmt.Errorf("no saved token found, please run 'tidal-playlist auth' first: %w", err)
+	}
+	_, err = a.RefreshToken(ctx, token)
+	return err
+}
+
+// currentStaticToken returns the token supplied via UseToken, or nil.
+func (a *AuthManager) currentStaticToken() *oauth2.Token {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.staticToken
+}
+
+// refreshStaticToken refreshes a token supplied via UseToken. The lock is
+// held during the refresh, so that concurrent rejections refresh one after
+// another with the latest refresh token.
+func (a *AuthManager) refreshStaticToken(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.staticToken == nil || a.staticToken.RefreshToken == "" {
+		return fmt.Errorf("no refresh token available")
+	}
+
+	// Drop the rejected access token so that the token source refreshes it.
+	expired := &oauth2.Token{RefreshToken: a.staticToken.RefreshToken}
+	newToken, err := a.config.TokenSource(ctx, expired).Token()
+	if err != nil {
+		return fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	a.staticToken = newToken
+	return nil
+}
+
 // LoginWithClientCredentials uses client credentials flow (simpler, no browser needed).
 func (a *AuthManager) LoginWithClientCredentials(ctx context.Context) (*oauth2.Token, error) {
 	// Create Basic Auth header
@@ -71,6 +174,7 @@ func (a *AuthManager) LoginWithClientCredentials(ctx context.Context) (*oauth2.T
 
 	req.Header.Set("Authorization", "Basic "+b64Creds)
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", a.userAgent)
 
 	// Execute request
 	client := &http.Client{Timeout: 30 * time.Second}
@@ -135,6 +239,15 @@ func (a *AuthManager) Login(ctx context.Context) (*oauth2.Token, error) {
 		return nil, fmt.Errorf("failed to generate PKCE: %w", err)
 	}
 
+	// Listen before building the auth URL, as the redirect URL has to name
+	// the port actually used.
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", a.callbackPort))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for the callback: %w", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	a.config.RedirectURL = fmt.Sprintf("http://localhost:%d/callback", port)
+
 	// Create OAuth config with PKCE
 	authURL := a.config.AuthCodeURL("state",
 		oauth2.SetAuthURLParam("code_challenge", challenge),
@@ -145,8 +258,11 @@ func (a *AuthManager) Login(ctx context.Context) (*oauth2.Token, error) {
 	codeChan := make(chan string, 1)
 	errChan := make(chan error, 1)
 
-	server := &http.Server{Addr: ":8080"}
-	http.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+	// A mux of its own per login, so that logging in again doesn't register
+	// the callback on the default mux twice.
+	mux := http.NewServeMux()
+	server := &http.Server{Handler: mux}
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
 		code := r.URL.Query().Get("code")
 		if code == "" {
 			errChan <- fmt.Errorf("no code in callback")
@@ -158,10 +274,13 @@ func (a *AuthManager) Login(ctx context.Context) (*oauth2.Token, error) {
 	})
 
 	go func() {
-		if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		if err := server.Serve(listener); err != http.ErrServerClosed {
 			errChan <- err
 		}
 	}()
+	// Close the server and its listener on every return, so that the port
+	// is free for the next login.
+	defer server.Close()
 
 	fmt.Println("Please open the following URL in your browser to authenticate:")
 	fmt.Println(authURL)
@@ -174,8 +293,10 @@ func (a *AuthManager) Login(ctx context.Context) (*oauth2.Token, error) {
 		// Got the code
 	case err := <-errChan:
 		return nil, fmt.Errorf("callback server error: %w", err)
-	case <-time.After(5 * time.Minute):
+	case <-time.After(a.loginTimeout):
 		return nil, fmt.Errorf("authentication timeout")
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
 
 	// Shutdown the server
@@ -199,9 +320,24 @@ func (a *AuthManager) Login(ctx context.Context) (*oauth2.Token, error) {
 	return token, nil
 }
 
-// LoadToken loads a saved OAuth token from file.
+// LoadToken loads a saved OAuth token from the token store.
 func (a *AuthManager) LoadToken() (*oauth2.Token, error) {
-	data, err := os.ReadFile(a.tokenFile)
+	token, err := a.readToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if token is expired and needs refresh
+	if token.Expiry.Before(time.Now()) && token.RefreshToken != "" {
+		return a.RefreshToken(context.Background(), token)
+	}
+
+	return token, nil
+}
+
+// readToken reads the saved OAuth token from the token store as is.
+func (a *AuthManager) readToken() (*oauth2.Token, error) {
+	data, err := a.readTokenData()
 	if err != nil {
 		return nil, err
 	}
@@ -211,29 +347,16 @@ func (a *AuthManager) LoadToken() (*oauth2.Token, error) {
 		return nil, err
 	}
 
-	token := &oauth2.Token{
+	return &oauth2.Token{
 		AccessToken:  storedToken.AccessToken,
 		RefreshToken: storedToken.RefreshToken,
 		TokenType:    storedToken.TokenType,
 		Expiry:       storedToken.ExpiresAt,
-	}
-
-	// Check if token is expired and needs refresh
-	if token.Expiry.Before(time.Now()) && token.RefreshToken != "" {
-		return a.RefreshToken(context.Background(), token)
-	}
-
-	return token, nil
+	}, nil
 }
 
-// SaveToken saves an OAuth token to file.
+// SaveToken saves an OAuth token to the token store.
 func (a *AuthManager) SaveToken(token *oauth2.Token) error {
-	// Create config directory if it doesn't exist
-	dir := filepath.Dir(a.tokenFile)
-	if err := os.MkdirAll(dir, 0700); err != nil {
-		return err
-	}
-
 	storedToken := models.OAuth2Token{
 		AccessToken:  token.AccessToken,
 		RefreshToken: token.RefreshToken,
@@ -246,7 +369,7 @@ func (a *AuthManager) SaveToken(token *oauth2.Token) error {
 		return err
 	}
 
-	return os.WriteFile(a.tokenFile, data, 0600)
+	return a.writeTokenData(data)
 }
 
 // RefreshToken refreshes an expired OAuth token.
@@ -272,6 +395,19 @@ func (a *AuthManager) RefreshToken(ctx context.Context, token *oauth2.Token) (*o
 
 // GetValidToken returns a valid token, refreshing if necessary.
 func (a *AuthManager) GetValidToken(ctx context.Context) (*oauth2.Token, error) {
+	// A supplied token has no known expiry, so it is used as is.
+	if token := a.currentStaticToken(); token != nil {
+		return token, nil
+	}
+
+	if a.trustToken {
+		token, err := a.readToken()
+		if err != nil {
+			return nil, fmt.Errorf("no saved token found, please run 'tidal-playlist auth' first: %w", err)
+		}
+		return token, nil
+	}
+
 	token, err := a.LoadToken()
 	if err != nil {
 		return nil, fmt.Errorf("no saved token found, please run 'tidal-playlist auth' first: %w", err)
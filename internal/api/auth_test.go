@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// freePort returns a port that is free at the moment.
+func freePort(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// callCallback calls the callback of a running Login with the given query,
+// retrying until its server is up.
+func callCallback(t *testing.T, port int, query string) {
+	t.Helper()
+	url := fmt.Sprintf("http://localhost:%d/callback?%s", port, query)
+	for range 100 {
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Errorf("callback server on port %d never came up", port)
+}
+
+func TestLoginTwice(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"access","refresh_token":"refresh","token_type":"Bearer","expires_in":3600}`)
+	}))
+	defer tokenServer.Close()
+
+	a := NewAuthManager("client-id", "client-secret")
+	a.tokenFile = filepath.Join(t.TempDir(), "token.json")
+	a.config.Endpoint.TokenURL = tokenServer.URL
+	port := freePort(t)
+	a.UseCallbackPort(port)
+
+	ctx := context.Background()
+
+	// The first login fails, as the callback carries no code. The second
+	// one on the same port has to work nonetheless.
+	go callCallback(t, port, "error=access_denied")
+	if _, err := a.Login(ctx); err == nil {
+		t.Fatal("first Login succeeded without a code")
+	}
+
+	go callCallback(t, port, "code=abc&state=state")
+	token, err := a.Login(ctx)
+	if err != nil {
+		t.Fatalf("second Login failed: %v", err)
+	}
+	if token.AccessToken != "access" {
+		t.Errorf("got access token %q, want %q", token.AccessToken, "access")
+	}
+	if want := fmt.Sprintf("http://localhost:%d/callback", port); a.config.RedirectURL != want {
+		t.Errorf("redirect URL is %q, want %q", a.config.RedirectURL, want)
+	}
+}
+
+func TestLoginAfterTimeoutOnFixedPort(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"access","refresh_token":"refresh","token_type":"Bearer","expires_in":3600}`)
+	}))
+	defer tokenServer.Close()
+
+	a := NewAuthManager("client-id", "client-secret")
+	a.tokenFile = filepath.Join(t.TempDir(), "token.json")
+	a.config.Endpoint.TokenURL = tokenServer.URL
+	a.loginTimeout = 50 * time.Millisecond
+	port := freePort(t)
+	a.UseCallbackPort(port)
+
+	ctx := context.Background()
+	if _, err := a.Login(ctx); err == nil {
+		t.Fatal("Login without a callback didn't time out")
+	}
+
+	a.loginTimeout = defaultLoginTimeout
+	go callCallback(t, port, "code=abc&state=state")
+	if _, err := a.Login(ctx); err != nil {
+		t.Fatalf("Login after a timeout failed: %v", err)
+	}
+}
+
+func TestRefreshStaticTokenConcurrently(t *testing.T) {
+	var mu sync.Mutex
+	refreshes := 0
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		refreshes++
+		n := refreshes
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"access-%d","refresh_token":"refresh","token_type":"Bearer","expires_in":3600}`, n)
+	}))
+	defer tokenServer.Close()
+
+	a := NewAuthManager("client-id", "client-secret")
+	a.config.Endpoint.TokenURL = tokenServer.URL
+	a.UseToken("access-0", "refresh")
+
+	// Requests read the token while rejected ones refresh it.
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			if err := a.reauthenticate(ctx); err != nil {
+				t.Errorf("reauthenticate failed: %v", err)
+			}
+		})
+		wg.Go(func() {
+			if _, err := a.GetValidToken(ctx); err != nil {
+				t.Errorf("GetValidToken failed: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	token, err := a.GetValidToken(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := fmt.Sprintf("access-%d", refreshes); token.AccessToken != want {
+		t.Errorf("token = %s, want the latest one %s", token.AccessToken, want)
+	}
+}
@@ -4,55 +4,197 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/aligator/tidal-playlist/internal/config"
 	"github.com/aligator/tidal-playlist/internal/models"
 	"golang.org/x/oauth2"
+	"golang.org/x/time/rate"
 )
 
 const (
 	baseURL = "https://openapi.tidal.com"
+
+	// MaxItemsPerRequest is the maximum number of resources the API accepts
+	// in a single request, e.g. per relationship request or ID filter.
+	MaxItemsPerRequest = 20
+
+	// lowRateLimitRemaining is the remaining request budget below which
+	// requests are spread out until the rate limit resets.
+	lowRateLimitRemaining = 10
 )
 
+// ErrAPIDown is returned without sending a request once too many consecutive
+// requests have failed.
+var ErrAPIDown = errors.New("API appears to be down")
+
 // Client represents a Tidal API client.
 type Client struct {
 	httpClient  *http.Client
 	baseURL     string
 	authMgr     *AuthManager
-	rateLimiter chan struct{}
+	concurrency chan struct{} // one slot per request in flight
 	config      *config.Config
+
+	// limiter spaces out the start of requests
+	limiter *rate.Limiter
+
+	// retryClassifier decides how error statuses are handled
+	retryClassifier RetryClassifier
+
+	// Circuit breaker state
+	mu                  sync.Mutex
+	consecutiveFailures int
+
+	// ID of the current user, fetched once
+	userID string
+
+	// Latest rate limit state reported by the API, -1 if unknown
+	rateLimitRemaining int
+	rateLimitReset     time.Time
+
+	// Request statistics
+	requests       int
+	requestLatency time.Duration
+}
+
+// RequestStats summarizes the requests sent by a client.
+type RequestStats struct {
+	Requests       int
+	AverageLatency time.Duration
+}
+
+// recordLatency counts a sent request and how long it took.
+func (c *Client) recordLatency(latency time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests++
+	c.requestLatency += latency
+}
+
+// Stats returns statistics about the requests sent so far.
+func (c *Client) Stats() RequestStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := RequestStats{Requests: c.requests}
+	if c.requests > 0 {
+		stats.AverageLatency = c.requestLatency / time.Duration(c.requests)
+	}
+	return stats
 }
 
 // NewClient creates a new Tidal API client.
 func NewClient(authMgr *AuthManager, config *config.Config) *Client {
+	if config.Tidal.UserAgent != "" {
+		authMgr.userAgent = config.Tidal.UserAgent
+	}
+
 	return &Client{
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		baseURL:     baseURL,
-		authMgr:     authMgr,
-		rateLimiter: make(chan struct{}, 1), // Allow 1 request at a time
-		config:      config,
+		baseURL:            baseURL,
+		authMgr:            authMgr,
+		concurrency:        make(chan struct{}, config.Tidal.MaxConcurrent),
+		config:             config,
+		limiter:            rate.NewLimiter(rate.Limit(config.Tidal.RequestsPerSecond), 1),
+		retryClassifier:    retryClassifierFromConfig(config.Tidal.StatusActions),
+		rateLimitRemaining: -1,
+	}
+}
+
+// UseBaseURL makes the client send its requests to the given URL instead of
+// the Tidal API, e.g. to a test server.
+func (c *Client) UseBaseURL(url string) {
+	c.baseURL = url
+}
+
+// doRequest performs an HTTP request with authentication. Error statuses are
+// retried or re-authenticated according to the retry classifier.
+func (c *Client) doRequest(ctx context.Context, method, endpoint string, body []byte) (*http.Response, error) {
+	retries := 0
+	reauthenticated := false
+	for {
+		resp, err := c.send(ctx, method, endpoint, body)
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			return resp, err
+		}
+
+		switch c.retryClassifier(method, apiErr.StatusCode) {
+		case ActionReauth:
+			if reauthenticated {
+				return nil, err
+			}
+			reauthenticated = true
+			if err := c.authMgr.reauthenticate(ctx); err != nil {
+				return nil, fmt.Errorf("%w (re-authentication failed: %v)", apiErr, err)
+			}
+		case ActionRetry:
+			if retries >= c.config.Tidal.MaxRetries {
+				return nil, err
+			}
+			retries++
+
+			// Wait as long as the API asks for, or back off otherwise.
+			delay := apiErr.RetryAfter
+			if delay <= 0 {
+				delay = time.Duration(retries) * time.Second
+			}
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		default:
+			return nil, err
+		}
 	}
 }
 
-// doRequest performs an HTTP request with authentication.
-func (c *Client) doRequest(ctx context.Context, method, endpoint string, body io.Reader) (*http.Response, error) {
-	// Rate limiting: acquire semaphore
-	c.rateLimiter <- struct{}{}
-	defer func() {
-		// Release after a delay
-		time.Sleep(300 * time.Millisecond)
-		<-c.rateLimiter
-	}()
+// send performs a single HTTP request with authentication.
+func (c *Client) send(ctx context.Context, method, endpoint string, body []byte) (*http.Response, error) {
+	// Don't start new requests once the operation was cancelled.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Rate limiting: acquire a slot, then wait for the limiter
+	select {
+	case c.concurrency <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-c.concurrency }()
+
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := c.checkCircuit(); err != nil {
+		return nil, err
+	}
+
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
 
 	url := c.baseURL + endpoint
 
-	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -67,28 +209,237 @@ func (c *Client) doRequest(ctx context.Context, method, endpoint string, body io
 	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
 	req.Header.Set("Content-Type", "application/vnd.api+json")
 	req.Header.Set("Accept", "application/vnd.api+json")
+	req.Header.Set("User-Agent", c.authMgr.userAgent)
+	if c.config.Tidal.Language != "" {
+		req.Header.Set("Accept-Language", c.config.Tidal.Language)
+	}
 
+	sentAt := time.Now()
 	resp, err := c.httpClient.Do(req)
+	c.recordLatency(time.Since(sentAt))
 	if err != nil {
+		// A cancelled request says nothing about the state of the API.
+		if ctx.Err() == nil {
+			c.recordResult(false)
+		}
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 
+	// Client errors mean the API itself is up.
+	c.recordResult(resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests)
+	c.updateRateLimit(resp.Header)
+
 	// Check for API errors
 	if resp.StatusCode >= 400 {
 		defer resp.Body.Close()
 		bodyBytes, _ := io.ReadAll(resp.Body)
 
-		var apiErr models.ErrorResponse
-		if err := json.Unmarshal(bodyBytes, &apiErr); err == nil {
-			return nil, fmt.Errorf("API error (status %d): %s", apiErr.Status, apiErr.Message)
+		apiErr := &APIError{
+			StatusCode: resp.StatusCode,
+			Message:    string(bodyBytes),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+		var errResp models.ErrorResponse
+		if err := json.Unmarshal(bodyBytes, &errResp); err == nil && errResp.Message != "" {
+			apiErr.Message = errResp.Message
 		}
 
-		return nil, fmt.Errorf("HTTP error %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, apiErr
 	}
 
 	return resp, nil
 }
 
+// parseRetryAfter parses a Retry-After header, given either in seconds or as
+// HTTP date. Invalid or missing values result in 0.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+	return 0
+}
+
+// checkCircuit fails fast if the API is considered down.
+func (c *Client) checkCircuit() error {
+	limit := c.config.Tidal.MaxConsecutiveFailures
+	if limit <= 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.consecutiveFailures >= limit {
+		return fmt.Errorf("%w: %d consecutive requests failed", ErrAPIDown, c.consecutiveFailures)
+	}
+	return nil
+}
+
+// recordResult updates the circuit breaker with the outcome of a request.
+func (c *Client) recordResult(success bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if success {
+		c.consecutiveFailures = 0
+	} else {
+		c.consecutiveFailures++
+	}
+}
+
+// updateRateLimit stores the rate limit state reported in the response headers.
+func (c *Client) updateRateLimit(header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+
+	// The reset is either a unix timestamp or the seconds until the reset.
+	var reset time.Time
+	if value, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		if value > 1_000_000_000 {
+			reset = time.Unix(value, 0)
+		} else {
+			reset = time.Now().Add(time.Duration(value) * time.Second)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rateLimitRemaining = remaining
+	c.rateLimitReset = reset
+}
+
+// waitForRateLimit slows down requests as the remaining rate limit budget
+// drops, spreading the remaining requests until the limit resets.
+func (c *Client) waitForRateLimit(ctx context.Context) error {
+	c.mu.Lock()
+	remaining := c.rateLimitRemaining
+	untilReset := time.Until(c.rateLimitReset)
+	c.mu.Unlock()
+
+	if remaining < 0 || remaining >= lowRateLimitRemaining || untilReset <= 0 {
+		return nil
+	}
+
+	delay := untilReset
+	if remaining > 0 {
+		delay = untilReset / time.Duration(remaining+1)
+	}
+
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// APIError is returned when the API responds with an error status code.
+type APIError struct {
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration // from the Retry-After header, 0 if not sent
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Message)
+}
+
+// decodeResponse reads the response body and unmarshals it into v.
+// Empty bodies (e.g. 204 No Content) are not an error and leave v untouched.
+// The response body is always closed.
+func decodeResponse(resp *http.Response, v any) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if v == nil || len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return nil
+}
+
+// decodeStream decodes the top-level object of the response body member by
+// member instead of reading the whole body at once, so that large arrays
+// don't have to be kept in memory. decode is called for every member with
+// the decoder positioned at its value and has to consume that value.
+// The response body is always closed.
+func decodeStream(resp *http.Response, decode func(key string, dec *json.Decoder) error) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	if _, err := dec.Token(); err == io.EOF {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+		key, _ := token.(string)
+
+		if err := decode(key, dec); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// decodeArray calls decodeItem for every element of the array the decoder is
+// positioned at. decodeItem has to consume the element.
+func decodeArray(dec *json.Decoder, decodeItem func() error) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return nil // null instead of an array
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected an array, got %v", token)
+	}
+
+	for dec.More() {
+		if err := decodeItem(); err != nil {
+			return err
+		}
+	}
+
+	_, err = dec.Token() // closing ]
+	return err
+}
+
+// skipValue consumes the value the decoder is positioned at.
+func skipValue(dec *json.Decoder) error {
+	var skip json.RawMessage
+	return dec.Decode(&skip)
+}
+
 // get performs a GET request.
 func (c *Client) get(ctx context.Context, endpoint string) (*http.Response, error) {
 	return c.doRequest(ctx, http.MethodGet, endpoint, nil)
@@ -96,37 +447,52 @@ func (c *Client) get(ctx context.Context, endpoint string) (*http.Response, erro
 
 // post performs a POST request.
 func (c *Client) post(ctx context.Context, endpoint string, payload interface{}) (*http.Response, error) {
-	var body io.Reader
+	var body []byte
 	if payload != nil {
 		jsonData, err := json.Marshal(payload)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal payload: %w", err)
 		}
-		body = bytes.NewBuffer(jsonData)
+		body = jsonData
 	}
 	return c.doRequest(ctx, http.MethodPost, endpoint, body)
 }
 
 // patch performs a PATCH request.
 func (c *Client) patch(ctx context.Context, endpoint string, payload interface{}) (*http.Response, error) {
-	var body io.Reader
+	var body []byte
 	if payload != nil {
 		jsonData, err := json.Marshal(payload)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal payload: %w", err)
 		}
-		body = bytes.NewBuffer(jsonData)
+		body = jsonData
 	}
 	return c.doRequest(ctx, http.MethodPatch, endpoint, body)
 }
 
 // delete performs a DELETE request.
-func (c *Client) delete(ctx context.Context, endpoint string) (*http.Response, error) {
-	return c.doRequest(ctx, http.MethodDelete, endpoint, nil)
+func (c *Client) delete(ctx context.Context, endpoint string, payload interface{}) (*http.Response, error) {
+	var body []byte
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		body = jsonData
+	}
+	return c.doRequest(ctx, http.MethodDelete, endpoint, body)
 }
 
 // GetUserID retrieves the current user's ID.
 func (c *Client) GetUserID(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	userID := c.userID
+	c.mu.Unlock()
+	if userID != "" {
+		return userID, nil
+	}
+
 	// Tidal API doesn't have a simple userinfo endpoint
 	// We need to extract the user ID from the token or use a workaround
 	// Try using the OAuth userinfo endpoint (standard OAuth 2.0)
@@ -157,9 +523,26 @@ func (c *Client) GetUserID(ctx context.Context) (string, error) {
 		return "", fmt.Errorf("no user ID in response: %s", string(body))
 	}
 
+	c.mu.Lock()
+	c.userID = userResponse.Data.ID
+	c.mu.Unlock()
+
 	return userResponse.Data.ID, nil
 }
 
+// VerifyToken performs a test call to make sure the current token is accepted.
+// A rejected token is refreshed once, if a refresh token is available, before
+// giving up.
+func (c *Client) VerifyToken(ctx context.Context) error {
+	_, err := c.GetUserID(ctx)
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("the supplied access token is expired or invalid: %w", err)
+	}
+	return err
+}
+
 // WithToken creates a client with a specific token (for testing).
 func (c *Client) WithToken(token *oauth2.Token) *Client {
 	c.httpClient = oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(token))
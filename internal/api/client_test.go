@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aligator/tidal-playlist/internal/config"
+)
+
+// newTestClient returns a client sending its requests to a test server
+// with the given handler, authenticated with a static token.
+func newTestClient(t testing.TB, handler http.Handler) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{}
+	cfg.Tidal.CountryCode = "US"
+	cfg.Tidal.RequestsPerSecond = 1000
+	cfg.Tidal.MaxConcurrent = 1
+	cfg.Tidal.MaxRetries = 3
+
+	authMgr := NewAuthManager("client-id", "client-secret")
+	authMgr.UseToken("test-token", "")
+	client := NewClient(authMgr, cfg)
+	client.baseURL = server.URL
+	return client
+}
+
+func TestDecodeResponseNoContent(t *testing.T) {
+	var gotBody string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	resp, err := client.post(context.Background(), "/v2/playlists/p1/relationships/items", map[string]string{"type": "tracks"})
+	if err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+
+	result := struct {
+		Data []string `json:"data"`
+	}{Data: []string{"unchanged"}}
+	if err := decodeResponse(resp, &result); err != nil {
+		t.Fatalf("decodeResponse returned an error for 204: %v", err)
+	}
+	if len(result.Data) != 1 || result.Data[0] != "unchanged" {
+		t.Errorf("decodeResponse modified the target: %v", result.Data)
+	}
+	if gotBody != `{"type":"tracks"}` {
+		t.Errorf("server received body %q", gotBody)
+	}
+}
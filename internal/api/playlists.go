@@ -3,59 +3,133 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/aligator/tidal-playlist/internal/models"
 )
 
+// playlistResource is the JSON:API representation of a playlist.
+type playlistResource struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	Attributes struct {
+		Name           string      `json:"name"`
+		Description    string      `json:"description"`
+		CreatedAt      time.Time   `json:"createdAt"`
+		LastModifiedAt time.Time   `json:"lastModifiedAt"`
+		NumberOfItems  int         `json:"numberOfItems"`
+		AccessType     string      `json:"accessType"`
+		ImageLinks     []imageLink `json:"imageLinks"`
+	} `json:"attributes"`
+	Relationships struct {
+		Owners struct {
+			Data []struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		} `json:"owners"`
+	} `json:"relationships"`
+}
+
+// toModel converts the resource into a Playlist model.
+func (r playlistResource) toModel() models.Playlist {
+	var creator models.Creator
+	if owners := r.Relationships.Owners.Data; len(owners) > 0 {
+		creator.ID = owners[0].ID
+	}
+
+	return models.Playlist{
+		ID:             r.ID,
+		Name:           r.Attributes.Name,
+		Title:          r.Attributes.Name, // Copy to Title for compatibility
+		Description:    r.Attributes.Description,
+		Creator:        creator,
+		Created:        r.Attributes.CreatedAt,
+		LastUpdated:    r.Attributes.LastModifiedAt,
+		NumberOfTracks: r.Attributes.NumberOfItems,
+		AccessType:     r.Attributes.AccessType,
+		Images:         toImages(r.Attributes.ImageLinks),
+	}
+}
+
 // GetUserPlaylists retrieves all playlists for the current user.
 func (c *Client) GetUserPlaylists(ctx context.Context) ([]models.Playlist, error) {
+	var allPlaylists []models.Playlist
+	cursor := ""
+
+	for {
+		playlists, meta, err := c.GetUserPlaylistsPage(ctx, cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		allPlaylists = append(allPlaylists, playlists...)
+
+		// Check if there are more pages
+		if meta.NextCursor == "" {
+			break
+		}
+
+		cursor = meta.NextCursor
+	}
+
+	return allPlaylists, nil
+}
+
+// GetUserPlaylistsPage retrieves a single page of the current user's playlists.
+// Pass an empty cursor for the first page and the returned NextCursor for the
+// following ones. NextCursor is empty on the last page. Total is 0 if the API
+// doesn't report it.
+func (c *Client) GetUserPlaylistsPage(ctx context.Context, cursor string) ([]models.Playlist, models.Metadata, error) {
 	userID, err := c.GetUserID(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user ID: %w", err)
+		return nil, models.Metadata{}, fmt.Errorf("failed to get user ID: %w", err)
 	}
 
 	// Use /playlists endpoint with filter to get full playlist data
 	endpoint := fmt.Sprintf("/v2/playlists?filter[owners.id]=%s", userID)
-	resp, err := c.get(ctx, endpoint)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch playlists: %w", err)
+	if cursor != "" {
+		endpoint += fmt.Sprintf("&page[cursor]=%s", cursor)
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	resp, err := c.get(ctx, endpoint)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, models.Metadata{}, fmt.Errorf("failed to fetch playlists: %w", err)
 	}
 
 	// Parse JSON:API format with attributes
 	var apiResp struct {
-		Data []struct {
-			ID         string `json:"id"`
-			Type       string `json:"type"`
-			Attributes struct {
-				Name        string `json:"name"`
-				Description string `json:"description"`
-			} `json:"attributes"`
-		} `json:"data"`
+		Data []playlistResource `json:"data"`
+		Meta struct {
+			Total int `json:"total"`
+		} `json:"meta"`
+		Links struct {
+			Meta struct {
+				NextCursor string `json:"nextCursor"`
+			} `json:"meta"`
+		} `json:"links"`
 	}
-	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err := decodeResponse(resp, &apiResp); err != nil {
+		return nil, models.Metadata{}, err
 	}
 
 	// Convert to Playlist models
 	playlists := make([]models.Playlist, len(apiResp.Data))
 	for i, item := range apiResp.Data {
-		playlists[i] = models.Playlist{
-			ID:          item.ID,
-			Name:        item.Attributes.Name,
-			Title:       item.Attributes.Name,
-			Description: item.Attributes.Description,
-		}
+		playlists[i] = item.toModel()
+	}
+
+	meta := models.Metadata{
+		Total:      apiResp.Meta.Total,
+		Limit:      len(playlists),
+		NextCursor: apiResp.Links.Meta.NextCursor,
 	}
 
-	return playlists, nil
+	return playlists, meta, nil
 }
 
 // GetPlaylist retrieves a specific playlist by UUID.
@@ -65,92 +139,125 @@ func (c *Client) GetPlaylist(ctx context.Context, playlistUUID string) (*models.
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch playlist: %w", err)
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
 
 	// Parse JSON:API format with attributes
 	var apiResp struct {
-		Data struct {
-			ID         string `json:"id"`
-			Type       string `json:"type"`
-			Attributes struct {
-				Name        string `json:"name"`
-				Description string `json:"description"`
-			} `json:"attributes"`
-		} `json:"data"`
+		Data playlistResource `json:"data"`
 	}
-	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err := decodeResponse(resp, &apiResp); err != nil {
+		return nil, err
 	}
 
-	playlist := &models.Playlist{
-		ID:          apiResp.Data.ID,
-		Name:        apiResp.Data.Attributes.Name,
-		Title:       apiResp.Data.Attributes.Name, // Copy to Title for compatibility
-		Description: apiResp.Data.Attributes.Description,
-	}
-
-	return playlist, nil
+	playlist := apiResp.Data.toModel()
+	return &playlist, nil
 }
 
 // CreatePlaylist creates a new playlist.
+//
+// The API has no idempotency keys, so a request whose response got lost can't
+// simply be sent again: the playlist may have been created anyway. The first
+// attempt is therefore sent without the generic retries. If it fails with a
+// server error or no response at all, the playlists of the user are searched
+// for one with the same name created since the first attempt, and the request
+// is only repeated if there is none.
 func (c *Client) CreatePlaylist(ctx context.Context, title, description string) (*models.Playlist, error) {
+	// Allow for some clock difference between this machine and Tidal.
+	started := time.Now().Add(-time.Minute)
+
+	playlist, err := c.createPlaylist(ctx, title, description, false)
+	if err == nil || ctx.Err() != nil {
+		return playlist, err
+	}
+
+	// The API rejected the request without creating anything, so the
+	// usual error handling applies, e.g. waiting for a rate limit.
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode < 500 {
+		if c.retryClassifier(http.MethodPost, apiErr.StatusCode) == ActionFail {
+			return nil, err
+		}
+		return c.createPlaylist(ctx, title, description, true)
+	}
+
+	fmt.Printf("Warning: creating playlist '%s' failed (%v), checking whether it was created anyway...\n", title, err)
+	existing, findErr := c.FindAllPlaylistsByName(ctx, title)
+	if findErr != nil {
+		return nil, err
+	}
+	for _, playlist := range existing {
+		if !playlist.Created.Before(started) {
+			return &playlist, nil
+		}
+	}
+
+	return c.createPlaylist(ctx, title, description, true)
+}
+
+// createPlaylist sends a request to create a playlist. Without retry, only a
+// single request is sent, regardless of the retry classifier.
+func (c *Client) createPlaylist(ctx context.Context, title, description string, retry bool) (*models.Playlist, error) {
+	attributes := map[string]interface{}{
+		"name":        title,
+		"description": description,
+	}
+	if c.config.Playlist.AccessType != "" {
+		attributes["accessType"] = c.config.Playlist.AccessType
+	}
+
 	// JSON:API format
 	payload := map[string]interface{}{
 		"data": map[string]interface{}{
-			"type": "playlists",
-			"attributes": map[string]interface{}{
-				"name":        title,
-				"description": description,
-			},
+			"type":       "playlists",
+			"attributes": attributes,
 		},
 	}
 
-	resp, err := c.post(ctx, "/v2/playlists", payload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create playlist: %w", err)
+	var resp *http.Response
+	var err error
+	if retry {
+		resp, err = c.post(ctx, "/v2/playlists", payload)
+	} else {
+		var body []byte
+		body, err = json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		resp, err = c.send(ctx, http.MethodPost, "/v2/playlists", body)
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("failed to create playlist: %w", err)
 	}
 
 	// Parse JSON:API format with attributes
 	var apiResp struct {
-		Data struct {
-			ID         string `json:"id"`
-			Type       string `json:"type"`
-			Attributes struct {
-				Name        string `json:"name"`
-				Description string `json:"description"`
-			} `json:"attributes"`
-		} `json:"data"`
-	}
-	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		Data playlistResource `json:"data"`
 	}
-
-	playlist := &models.Playlist{
-		ID:          apiResp.Data.ID,
-		Name:        apiResp.Data.Attributes.Name,
-		Title:       apiResp.Data.Attributes.Name,
-		Description: apiResp.Data.Attributes.Description,
+	if err := decodeResponse(resp, &apiResp); err != nil {
+		return nil, err
 	}
 
-	return playlist, nil
+	playlist := apiResp.Data.toModel()
+	return &playlist, nil
 }
 
 // UpdatePlaylistMetadata updates a playlist's title and description.
+// Empty values are left unchanged.
 func (c *Client) UpdatePlaylistMetadata(ctx context.Context, playlistUUID, title, description string) error {
+	attributes := map[string]interface{}{}
+	if title != "" {
+		attributes["name"] = title
+	}
+	if description != "" {
+		attributes["description"] = description
+	}
+
+	// JSON:API format
 	payload := map[string]interface{}{
-		"title":       title,
-		"description": description,
+		"data": map[string]interface{}{
+			"type":       "playlists",
+			"id":         playlistUUID,
+			"attributes": attributes,
+		},
 	}
 
 	endpoint := fmt.Sprintf("/v2/playlists/%s", playlistUUID)
@@ -158,9 +265,8 @@ func (c *Client) UpdatePlaylistMetadata(ctx context.Context, playlistUUID, title
 	if err != nil {
 		return fmt.Errorf("failed to update playlist: %w", err)
 	}
-	defer resp.Body.Close()
 
-	return nil
+	return decodeResponse(resp, nil)
 }
 
 // SetPlaylistTracks sets tracks of a playlist.
@@ -183,11 +289,238 @@ func (c *Client) SetPlaylistTracks(ctx context.Context, playlistUUID string, tra
 	if err != nil {
 		return fmt.Errorf("failed to add tracks to playlist: %w", err)
 	}
-	defer resp.Body.Close()
+
+	return decodeResponse(resp, nil)
+}
+
+// RemovePlaylistItems removes every occurrence of the given tracks from a
+// playlist. It returns the number of removed entries.
+func (c *Client) RemovePlaylistItems(ctx context.Context, playlistUUID string, trackIDs []string) (int, error) {
+	items, err := c.GetPlaylistItems(ctx, playlistUUID)
+	if err != nil {
+		return 0, err
+	}
+
+	remove := make(map[string]bool, len(trackIDs))
+	for _, trackID := range trackIDs {
+		remove[trackID] = true
+	}
+
+	var matching []models.PlaylistItem
+	for _, item := range items {
+		if remove[item.ID] {
+			matching = append(matching, item)
+		}
+	}
+
+	if err := c.RemovePlaylistEntries(ctx, playlistUUID, matching); err != nil {
+		return 0, err
+	}
+	return len(matching), nil
+}
+
+// RemovePlaylistEntries removes exactly the given entries from a playlist,
+// identified by their item ID. Other occurrences of the same track are kept.
+func (c *Client) RemovePlaylistEntries(ctx context.Context, playlistUUID string, items []models.PlaylistItem) error {
+	endpoint := fmt.Sprintf("/v2/playlists/%s/relationships/items", playlistUUID)
+	for i := 0; i < len(items); i += MaxItemsPerRequest {
+		end := min(i+MaxItemsPerRequest, len(items))
+
+		data := make([]map[string]interface{}, 0, end-i)
+		for _, item := range items[i:end] {
+			data = append(data, map[string]interface{}{
+				"type": item.Type,
+				"id":   item.ID,
+				"meta": map[string]string{
+					"itemId": item.ItemID,
+				},
+			})
+		}
+
+		resp, err := c.delete(ctx, endpoint, map[string]interface{}{"data": data})
+		if err != nil {
+			return fmt.Errorf("failed to remove tracks from playlist: %w", err)
+		}
+		if err := decodeResponse(resp, nil); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
+// GetPlaylistItems retrieves all items of a playlist in playlist order.
+func (c *Client) GetPlaylistItems(ctx context.Context, playlistUUID string) ([]models.PlaylistItem, error) {
+	var items []models.PlaylistItem
+	cursor := ""
+
+	for {
+		endpoint := fmt.Sprintf("/v2/playlists/%s/relationships/items?countryCode=%s", playlistUUID, c.config.Tidal.CountryCode)
+		if cursor != "" {
+			endpoint += fmt.Sprintf("&page[cursor]=%s", cursor)
+		}
+
+		resp, err := c.get(ctx, endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch playlist items: %w", err)
+		}
+
+		var apiResp struct {
+			Data []struct {
+				ID   string `json:"id"`
+				Type string `json:"type"`
+				Meta struct {
+					ItemID string `json:"itemId"`
+				} `json:"meta"`
+			} `json:"data"`
+			Links struct {
+				Meta struct {
+					NextCursor string `json:"nextCursor"`
+				} `json:"meta"`
+			} `json:"links"`
+		}
+		if err := decodeResponse(resp, &apiResp); err != nil {
+			return nil, err
+		}
+
+		for _, item := range apiResp.Data {
+			items = append(items, models.PlaylistItem{
+				ID:     item.ID,
+				ItemID: item.Meta.ItemID,
+				Type:   item.Type,
+			})
+		}
+
+		// Check if there are more pages
+		if apiResp.Links.Meta.NextCursor == "" {
+			break
+		}
+
+		cursor = apiResp.Links.Meta.NextCursor
+	}
+
+	return items, nil
+}
+
+// PartialWriteError is returned if adding tracks to a playlist failed after
+// some of the batches were already added.
+type PartialWriteError struct {
+	PlaylistID string
+	Added      []string // tracks that are in the playlist
+	Remaining  []string // tracks that still need to be added
+	Err        error
+}
+
+func (e *PartialWriteError) Error() string {
+	return fmt.Sprintf("added only %d of %d tracks to playlist %s: %v", len(e.Added), len(e.Added)+len(e.Remaining), e.PlaylistID, e.Err)
+}
+
+func (e *PartialWriteError) Unwrap() error {
+	return e.Err
+}
+
+// GetMix retrieves the tracks of a Tidal mix. The API serves mixes as
+// playlists, so the mix ID is used as playlist ID.
+func (c *Client) GetMix(ctx context.Context, mixID string) ([]models.Track, error) {
+	var tracks []models.Track
+	cursor := ""
+
+	for {
+		endpoint := fmt.Sprintf("/v2/playlists/%s/relationships/items?include=items&countryCode=%s", mixID, c.config.Tidal.CountryCode)
+		if cursor != "" {
+			endpoint += fmt.Sprintf("&page[cursor]=%s", cursor)
+		}
+
+		resp, err := c.get(ctx, endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch mix items: %w", err)
+		}
+
+		var apiResp struct {
+			Included []trackResource `json:"included"`
+			Links    struct {
+				Meta struct {
+					NextCursor string `json:"nextCursor"`
+				} `json:"meta"`
+			} `json:"links"`
+		}
+		if err := decodeResponse(resp, &apiResp); err != nil {
+			return nil, err
+		}
+
+		for _, item := range apiResp.Included {
+			if item.Type == "tracks" {
+				tracks = append(tracks, item.toModel(nil))
+			}
+		}
+
+		// Check if there are more pages
+		if apiResp.Links.Meta.NextCursor == "" {
+			break
+		}
+
+		cursor = apiResp.Links.Meta.NextCursor
+	}
+
+	return tracks, nil
+}
+
+// AddTracksToPlaylist appends tracks to a playlist in batches.
+// If a batch fails, a *PartialWriteError is returned.
+func (c *Client) AddTracksToPlaylist(ctx context.Context, playlistUUID string, trackIDs []string) error {
+	for i := 0; i < len(trackIDs); i += MaxItemsPerRequest {
+		end := i + MaxItemsPerRequest
+		if end > len(trackIDs) {
+			end = len(trackIDs)
+		}
+
+		batch := trackIDs[i:end]
+		if err := c.SetPlaylistTracks(ctx, playlistUUID, batch); err != nil {
+			return &PartialWriteError{
+				PlaylistID: playlistUUID,
+				Added:      trackIDs[:i],
+				Remaining:  trackIDs[i:],
+				Err:        err,
+			}
+		}
+
+		fmt.Printf("Added %d tracks...\n", end)
+	}
+
+	return nil
+}
+
+// addTracksWithRetries adds tracks to a playlist, retrying to add the
+// remaining tracks up to the configured number of times if adding fails.
+func (c *Client) addTracksWithRetries(ctx context.Context, playlistUUID string, trackIDs []string) error {
+	remaining := trackIDs
+	for attempt := 0; ; attempt++ {
+		err := c.AddTracksToPlaylist(ctx, playlistUUID, remaining)
+		if err == nil {
+			return nil
+		}
+
+		var partialErr *PartialWriteError
+		if !errors.As(err, &partialErr) {
+			return err
+		}
+		remaining = partialErr.Remaining
+		// Report the progress over all attempts.
+		partialErr.Added = trackIDs[:len(trackIDs)-len(remaining)]
+
+		if attempt >= c.config.Playlist.AddRetries || ctx.Err() != nil {
+			return partialErr
+		}
+
+		fmt.Printf("Adding tracks failed (%v), retrying to add the remaining %d tracks...\n", partialErr.Err, len(remaining))
+		select {
+		case <-time.After(time.Duration(attempt+1) * time.Second):
+		case <-ctx.Done():
+			return partialErr
+		}
+	}
+}
+
 // FindPlaylistByName finds a playlist by name (case-insensitive).
 func (c *Client) FindPlaylistByName(ctx context.Context, name string) (*models.Playlist, error) {
 	playlists, err := c.GetUserPlaylists(ctx)
@@ -221,16 +554,70 @@ func (c *Client) FindAllPlaylistsByName(ctx context.Context, name string) ([]mod
 	return matches, nil
 }
 
+// maxFuzzyDistance is the maximum edit distance for a fuzzy name match.
+const maxFuzzyDistance = 3
+
+// FindPlaylistsFuzzy finds playlists whose name is close to the given name:
+// it either contains the name ignoring case, or differs by only a few
+// characters. The closest matches come first.
+func (c *Client) FindPlaylistsFuzzy(ctx context.Context, name string) ([]models.Playlist, error) {
+	playlists, err := c.GetUserPlaylists(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user playlists: %w", err)
+	}
+
+	wanted := strings.ToLower(name)
+	distances := make(map[string]int)
+	var matches []models.Playlist
+	for _, playlist := range playlists {
+		title := strings.ToLower(playlist.GetTitle())
+		distance := levenshtein(wanted, title)
+		if distance > maxFuzzyDistance && !strings.Contains(title, wanted) {
+			continue
+		}
+		distances[playlist.GetID()] = distance
+		matches = append(matches, playlist)
+	}
+
+	slices.SortStableFunc(matches, func(a, b models.Playlist) int {
+		return distances[a.GetID()] - distances[b.GetID()]
+	})
+	return matches, nil
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
 // DeletePlaylist deletes a playlist by UUID.
 func (c *Client) DeletePlaylist(ctx context.Context, playlistUUID string) error {
 	endpoint := fmt.Sprintf("/v2/playlists/%s", playlistUUID)
-	resp, err := c.delete(ctx, endpoint)
+	resp, err := c.delete(ctx, endpoint, nil)
 	if err != nil {
 		return fmt.Errorf("failed to delete playlist: %w", err)
 	}
-	defer resp.Body.Close()
 
-	return nil
+	return decodeResponse(resp, nil)
 }
 
 // CreateOrUpdatePlaylist creates a new playlist or updates an existing one.
@@ -241,6 +628,18 @@ func (c *Client) CreateOrUpdatePlaylist(ctx context.Context, name, description s
 		return nil, fmt.Errorf("failed to search for existing playlists: %w", err)
 	}
 
+	// Keep the existing playlist if it already contains exactly these tracks.
+	if c.config.Playlist.SkipUnchanged && len(existingPlaylists) == 1 {
+		unchanged, err := c.hasSameTracks(ctx, existingPlaylists[0].GetID(), trackIDs)
+		if err != nil {
+			return nil, err
+		}
+		if unchanged {
+			fmt.Printf("Playlist '%s' is already up to date\n", name)
+			return &existingPlaylists[0], nil
+		}
+	}
+
 	for _, playlist := range existingPlaylists {
 		err := c.DeletePlaylist(ctx, playlist.ID)
 		if err != nil {
@@ -255,21 +654,49 @@ func (c *Client) CreateOrUpdatePlaylist(ctx context.Context, name, description s
 		return nil, fmt.Errorf("failed to create playlist: %w", err)
 	}
 
-	// Add tracks in batches (API limit is 20 tracks per request)
-	batchSize := 20
-	for i := 0; i < len(trackIDs); i += batchSize {
-		end := i + batchSize
-		if end > len(trackIDs) {
-			end = len(trackIDs)
+	if err := c.addTracksWithRetries(ctx, playlist.GetID(), trackIDs); err != nil {
+		if !c.config.Playlist.CleanupOnFailure {
+			return nil, err
 		}
 
-		batch := trackIDs[i:end]
-		if err := c.SetPlaylistTracks(ctx, playlist.GetID(), batch); err != nil {
-			return nil, fmt.Errorf("failed to add tracks to playlist: %w", err)
+		// Don't leave a partially filled playlist behind.
+		fmt.Printf("Deleting partially written playlist '%s'...\n", name)
+		if deleteErr := c.DeletePlaylist(ctx, playlist.GetID()); deleteErr != nil {
+			return nil, fmt.Errorf("%w (cleanup failed: %v)", err, deleteErr)
 		}
-
-		fmt.Printf("Added %d tracks...\n", end)
+		// The partial write is gone with the playlist, only its cause is
+		// passed on.
+		var partialErr *PartialWriteError
+		if errors.As(err, &partialErr) {
+			err = partialErr.Err
+		}
+		return nil, fmt.Errorf("playlist was removed again: %w", err)
 	}
 
+	// The playlist was empty when it was created.
+	playlist.NumberOfTracks = len(trackIDs)
 	return playlist, nil
 }
+
+// hasSameTracks reports whether the playlist contains exactly the given set of tracks.
+func (c *Client) hasSameTracks(ctx context.Context, playlistUUID string, trackIDs []string) (bool, error) {
+	items, err := c.GetPlaylistItems(ctx, playlistUUID)
+	if err != nil {
+		return false, err
+	}
+
+	existing := make(map[string]bool, len(items))
+	for _, item := range items {
+		existing[item.ID] = true
+	}
+
+	wanted := make(map[string]bool, len(trackIDs))
+	for _, trackID := range trackIDs {
+		if !existing[trackID] {
+			return false, nil
+		}
+		wanted[trackID] = true
+	}
+
+	return len(wanted) == len(existing), nil
+}
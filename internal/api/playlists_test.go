@@ -0,0 +1,199 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lostResponseServer serves playlist creation, where the response to the
+// first POST is dropped by closing the connection. With createFirst, the
+// playlist is created before the response is dropped.
+type lostResponseServer struct {
+	createFirst bool
+
+	mu        sync.Mutex
+	posts     int
+	playlists []map[string]any
+}
+
+func (s *lostResponseServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/v2/users/me":
+		fmt.Fprint(w, `{"data":{"id":"user-1"}}`)
+	case r.Method == http.MethodGet && r.URL.Path == "/v2/playlists":
+		json.NewEncoder(w).Encode(map[string]any{"data": s.playlists})
+	case r.Method == http.MethodPost && r.URL.Path == "/v2/playlists":
+		s.posts++
+		var payload struct {
+			Data struct {
+				Attributes struct {
+					Name string `json:"name"`
+				} `json:"attributes"`
+			} `json:"data"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+
+		playlist := map[string]any{
+			"id":   fmt.Sprintf("playlist-%d", s.posts),
+			"type": "playlists",
+			"attributes": map[string]any{
+				"name":      payload.Data.Attributes.Name,
+				"createdAt": time.Now().UTC().Format(time.RFC3339),
+			},
+		}
+		if s.posts > 1 || s.createFirst {
+			s.playlists = append(s.playlists, playlist)
+		}
+
+		if s.posts == 1 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]any{"data": playlist})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestCreatePlaylistLostResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		createFirst bool
+		wantPosts   int
+		wantID      string
+	}{
+		{name: "created despite the lost response", createFirst: true, wantPosts: 1, wantID: "playlist-1"},
+		{name: "not created", createFirst: false, wantPosts: 2, wantID: "playlist-2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &lostResponseServer{createFirst: tt.createFirst}
+			client := newTestClient(t, server)
+
+			playlist, err := client.CreatePlaylist(context.Background(), "Mix", "")
+			if err != nil {
+				t.Fatalf("CreatePlaylist failed: %v", err)
+			}
+			if playlist.GetID() != tt.wantID {
+				t.Errorf("got playlist %s, want %s", playlist.GetID(), tt.wantID)
+			}
+			if server.posts != tt.wantPosts {
+				t.Errorf("sent %d create requests, want %d", server.posts, tt.wantPosts)
+			}
+			if len(server.playlists) != 1 {
+				t.Errorf("server has %d playlists, want exactly 1", len(server.playlists))
+			}
+		})
+	}
+}
+
+func TestCreatePlaylistGatewayErrorIsNotResent(t *testing.T) {
+	server := &lostResponseServer{createFirst: true}
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Create the playlist, but answer with a gateway timeout.
+		if r.Method == http.MethodPost && server.posts == 0 {
+			server.mu.Lock()
+			server.posts++
+			server.playlists = append(server.playlists, map[string]any{
+				"id":         "playlist-1",
+				"type":       "playlists",
+				"attributes": map[string]any{"name": "Mix", "createdAt": time.Now().UTC().Format(time.RFC3339)},
+			})
+			server.mu.Unlock()
+			w.WriteHeader(http.StatusGatewayTimeout)
+			return
+		}
+		server.ServeHTTP(w, r)
+	}))
+
+	playlist, err := client.CreatePlaylist(context.Background(), "Mix", "")
+	if err != nil {
+		t.Fatalf("CreatePlaylist failed: %v", err)
+	}
+	if playlist.GetID() != "playlist-1" {
+		t.Errorf("got playlist %s, want playlist-1", playlist.GetID())
+	}
+	if server.posts != 1 {
+		t.Errorf("sent %d create requests, want 1", server.posts)
+	}
+}
+
+func TestCreateOrUpdatePlaylistCleanupError(t *testing.T) {
+	var deleted []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/users/me":
+			fmt.Fprint(w, `{"data":{"id":"user-1"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/playlists":
+			fmt.Fprint(w, `{"data":[]}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/playlists":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"data":{"id":"playlist-1","type":"playlists","attributes":{"name":"Mix"}}}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/playlists/playlist-1/relationships/items":
+			http.Error(w, "invalid track", http.StatusBadRequest)
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	client.config.Playlist.CleanupOnFailure = true
+
+	_, err := client.CreateOrUpdatePlaylist(context.Background(), "Mix", "", []string{"1", "2"})
+	if err == nil {
+		t.Fatal("CreateOrUpdatePlaylist succeeded")
+	}
+	if len(deleted) != 1 || deleted[0] != "/v2/playlists/playlist-1" {
+		t.Errorf("deleted %v, want the created playlist", deleted)
+	}
+
+	// The cause stays available, but the removed playlist isn't reported
+	// as partially written.
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("error %v doesn't wrap the API error", err)
+	}
+	var partialErr *PartialWriteError
+	if errors.As(err, &partialErr) {
+		t.Errorf("error %v reports a partial write of the removed playlist", err)
+	}
+}
+
+func TestAddTracksWithRetriesStopsOnCancel(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid track", http.StatusBadRequest)
+	}))
+	client.config.Playlist.AddRetries = 3
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	err := client.addTracksWithRetries(ctx, "playlist-1", []string{"1", "2"})
+	if elapsed := time.Since(started); elapsed > 900*time.Millisecond {
+		t.Errorf("returned after %v, the backoff ignored the cancellation", elapsed)
+	}
+
+	var partialErr *PartialWriteError
+	if !errors.As(err, &partialErr) {
+		t.Fatalf("error %v is no partial write", err)
+	}
+	if len(partialErr.Remaining) != 2 || len(partialErr.Added) != 0 {
+		t.Errorf("added %v, remaining %v", partialErr.Added, partialErr.Remaining)
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// RetryAction is what happens after the API responded with an error status.
+type RetryAction int
+
+const (
+	// ActionFail returns the error right away.
+	ActionFail RetryAction = iota
+	// ActionRetry sends the request again after the time asked for in the
+	// Retry-After header or a short backoff, up to tidal.max_retries times.
+	ActionRetry
+	// ActionReauth refreshes the token and sends the request again, once.
+	ActionReauth
+)
+
+// RetryClassifier decides what happens after an error status of a request
+// sent with the given method.
+type RetryClassifier func(method string, statusCode int) RetryAction
+
+// DefaultRetryClassifier re-authenticates on 401 and retries on rate limits
+// and temporary server errors. A POST may have been processed despite a 502
+// or 504 from a gateway, so it is only retried on 429 and 503, where the API
+// didn't process it. Everything else fails.
+func DefaultRetryClassifier(method string, statusCode int) RetryAction {
+	switch statusCode {
+	case http.StatusUnauthorized:
+		return ActionReauth
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return ActionRetry
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		if method != http.MethodPost {
+			return ActionRetry
+		}
+	}
+	return ActionFail
+}
+
+// parseRetryAction parses the name of an action: retry, reauth or fail.
+func parseRetryAction(name string) (RetryAction, bool) {
+	switch name {
+	case "retry":
+		return ActionRetry, true
+	case "reauth":
+		return ActionReauth, true
+	case "fail":
+		return ActionFail, true
+	}
+	return ActionFail, false
+}
+
+// retryClassifierFromConfig returns the default classifier with the actions
+// of tidal.status_actions taking precedence. Invalid entries are rejected by
+// the config validation.
+func retryClassifierFromConfig(statusActions map[string]string) RetryClassifier {
+	overrides := make(map[int]RetryAction, len(statusActions))
+	for status, name := range statusActions {
+		code, err := strconv.Atoi(status)
+		if err != nil {
+			continue
+		}
+		if action, ok := parseRetryAction(name); ok {
+			overrides[code] = action
+		}
+	}
+
+	return func(method string, statusCode int) RetryAction {
+		if action, ok := overrides[statusCode]; ok {
+			return action
+		}
+		return DefaultRetryClassifier(method, statusCode)
+	}
+}
+
+// SetRetryClassifier replaces how error statuses are handled.
+func (c *Client) SetRetryClassifier(classifier RetryClassifier) {
+	c.retryClassifier = classifier
+}
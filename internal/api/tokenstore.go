@@ -0,0 +1,61 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+)
+
+// keyringService is the service the tokens are stored under in the OS
+// keyring.
+const keyringService = "tidal-playlist"
+
+// keyringUser returns the keyring entry of the token, named like the token
+// file so that every profile gets its own entry.
+func (a *AuthManager) keyringUser() string {
+	return strings.TrimSuffix(filepath.Base(a.tokenFile), ".json")
+}
+
+// readTokenData reads the stored token JSON from the keyring or the token
+// file. A token missing in the keyring is read from the file, so a token
+// saved before switching to the keyring keeps working.
+func (a *AuthManager) readTokenData() ([]byte, error) {
+	if a.useKeyring {
+		data, err := keyring.Get(keyringService, a.keyringUser())
+		if err == nil {
+			return []byte(data), nil
+		}
+		if !errors.Is(err, keyring.ErrNotFound) {
+			fmt.Fprintf(os.Stderr, "Warning: keyring not available, reading the token from %s: %v\n", a.tokenFile, err)
+		}
+	}
+
+	return os.ReadFile(a.tokenFile)
+}
+
+// writeTokenData writes the token JSON to the keyring or the token file.
+func (a *AuthManager) writeTokenData(data []byte) error {
+	if a.useKeyring {
+		err := keyring.Set(keyringService, a.keyringUser(), string(data))
+		if err == nil {
+			// Don't leave a plaintext copy of the token behind.
+			if err := os.Remove(a.tokenFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("failed to remove token file: %w", err)
+			}
+			return nil
+		}
+		fmt.Fprintf(os.Stderr, "Warning: keyring not available, saving the token to %s: %v\n", a.tokenFile, err)
+	}
+
+	// Create config directory if it doesn't exist
+	dir := filepath.Dir(a.tokenFile)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(a.tokenFile, data, 0600)
+}
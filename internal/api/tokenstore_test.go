@@ -0,0 +1,134 @@
+package api
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/oauth2"
+)
+
+// newStoreAuthManager returns a manager with its token file in a temporary
+// directory, using the keyring if useKeyring is set.
+func newStoreAuthManager(t *testing.T, useKeyring bool) *AuthManager {
+	t.Helper()
+	a := NewAuthManager("client-id", "client-secret")
+	a.tokenFile = filepath.Join(t.TempDir(), "token.json")
+	if useKeyring {
+		a.UseKeyring()
+	}
+	return a
+}
+
+func testToken(accessToken string) *oauth2.Token {
+	return &oauth2.Token{
+		AccessToken:  accessToken,
+		RefreshToken: "refresh-" + accessToken,
+		TokenType:    "Bearer",
+		Expiry:       time.Now().Add(time.Hour).Truncate(time.Second),
+	}
+}
+
+func assertToken(t *testing.T, a *AuthManager, want string) {
+	t.Helper()
+	token, err := a.LoadToken()
+	if err != nil {
+		t.Fatalf("LoadToken failed: %v", err)
+	}
+	if token.AccessToken != want || token.RefreshToken != "refresh-"+want {
+		t.Errorf("loaded token %q/%q, want %q", token.AccessToken, token.RefreshToken, want)
+	}
+}
+
+func fileExists(t *testing.T, path string) bool {
+	t.Helper()
+	_, err := os.Stat(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		t.Fatal(err)
+	}
+	return err == nil
+}
+
+func TestTokenStoreFile(t *testing.T) {
+	keyring.MockInit()
+	a := newStoreAuthManager(t, false)
+
+	if err := a.SaveToken(testToken("file")); err != nil {
+		t.Fatalf("SaveToken failed: %v", err)
+	}
+	if !fileExists(t, a.tokenFile) {
+		t.Error("token file was not written")
+	}
+	if _, err := keyring.Get(keyringService, a.keyringUser()); !errors.Is(err, keyring.ErrNotFound) {
+		t.Errorf("the file store wrote to the keyring: %v", err)
+	}
+	assertToken(t, a, "file")
+}
+
+func TestTokenStoreKeyring(t *testing.T) {
+	keyring.MockInit()
+	a := newStoreAuthManager(t, true)
+
+	// A plaintext token of the file store is replaced by the keyring.
+	if err := os.WriteFile(a.tokenFile, []byte(`{"access_token":"old"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.SaveToken(testToken("keyring")); err != nil {
+		t.Fatalf("SaveToken failed: %v", err)
+	}
+	if fileExists(t, a.tokenFile) {
+		t.Error("the plaintext token file was left behind")
+	}
+	if _, err := keyring.Get(keyringService, "token"); err != nil {
+		t.Errorf("token not in the keyring: %v", err)
+	}
+	assertToken(t, a, "keyring")
+}
+
+func TestTokenStoreKeyringReadsFileToken(t *testing.T) {
+	keyring.MockInit()
+
+	// Saved before switching to the keyring.
+	fileStore := newStoreAuthManager(t, false)
+	if err := fileStore.SaveToken(testToken("before")); err != nil {
+		t.Fatal(err)
+	}
+
+	a := newStoreAuthManager(t, true)
+	a.tokenFile = fileStore.tokenFile
+	assertToken(t, a, "before")
+}
+
+func TestTokenStoreKeyringUnavailable(t *testing.T) {
+	keyring.MockInitWithError(errors.New("no secret service"))
+	t.Cleanup(keyring.MockInit)
+	a := newStoreAuthManager(t, true)
+
+	if err := a.SaveToken(testToken("fallback")); err != nil {
+		t.Fatalf("SaveToken failed: %v", err)
+	}
+	if !fileExists(t, a.tokenFile) {
+		t.Error("token file was not written as fallback")
+	}
+	assertToken(t, a, "fallback")
+}
+
+func TestTokenStoreKeyringPerProfile(t *testing.T) {
+	keyring.MockInit()
+	defaultProfile := newStoreAuthManager(t, true)
+	work := newStoreAuthManager(t, true)
+	work.UseProfile("work")
+
+	if err := defaultProfile.SaveToken(testToken("default")); err != nil {
+		t.Fatal(err)
+	}
+	if err := work.SaveToken(testToken("work")); err != nil {
+		t.Fatal(err)
+	}
+	assertToken(t, defaultProfile, "default")
+	assertToken(t, work, "work")
+}
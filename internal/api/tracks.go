@@ -4,11 +4,103 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/aligator/tidal-playlist/internal/models"
 )
 
+// trackResource is the JSON:API representation of a track.
+type trackResource struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	Attributes struct {
+		Title     string      `json:"title"`
+		Duration  isoDuration `json:"duration"`
+		Explicit  bool        `json:"explicit"`
+		ISRC      string      `json:"isrc"`
+		MediaTags []string    `json:"mediaTags"`
+	} `json:"attributes"`
+	Relationships struct {
+		Artists struct {
+			Data []models.ArtistID `json:"data"`
+		} `json:"artists"`
+	} `json:"relationships"`
+}
+
+// toModel converts the resource into a Track model. Artist names are taken
+// from artistNames where known.
+func (r trackResource) toModel(artistNames map[string]string) models.Track {
+	track := models.Track{
+		ID:       r.ID,
+		Title:    r.Attributes.Title,
+		Duration: int(time.Duration(r.Attributes.Duration).Seconds()),
+		Explicit: r.Attributes.Explicit,
+		ISRC:     r.Attributes.ISRC,
+		Quality:  qualityFromMediaTags(r.Attributes.MediaTags),
+	}
+
+	for _, artistID := range r.Relationships.Artists.Data {
+		artist := models.Artist{ID: artistID.ID}
+		artist.Attributes.Name = artistNames[artistID.ID]
+		track.Artists = append(track.Artists, artist)
+	}
+	if len(track.Artists) > 0 {
+		track.ArtistID = track.Artists[0].ID
+	}
+	return track
+}
+
+// qualityFromMediaTags returns the best audio quality listed in the media
+// tags. Tracks without lossless tags are available in HIGH quality only, the
+// quality is unknown if the API sent no media tags at all.
+func qualityFromMediaTags(tags []string) string {
+	if tags == nil {
+		return ""
+	}
+
+	quality := models.QualityHigh
+	for _, tag := range tags {
+		if models.QualityRank(tag) > models.QualityRank(quality) {
+			quality = tag
+		}
+	}
+	return quality
+}
+
+// isoDuration is an ISO 8601 duration like "PT3M25S" as used by the API.
+type isoDuration time.Duration
+
+func (d *isoDuration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	// An unknown duration must not fail the whole response.
+	duration, err := parseISODuration(value)
+	if err != nil {
+		duration = 0
+	}
+	*d = isoDuration(duration)
+	return nil
+}
+
+// parseISODuration parses the time part of an ISO 8601 duration.
+func parseISODuration(value string) (time.Duration, error) {
+	rest, ok := strings.CutPrefix(value, "PT")
+	if !ok {
+		return 0, fmt.Errorf("unsupported duration %q", value)
+	}
+
+	// Apart from the prefix the format matches Go durations in lower case.
+	if rest == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(strings.ToLower(rest))
+}
+
 // GetAlbumTracks retrieves all tracks from an album.
 func (c *Client) GetAlbumTracks(ctx context.Context, albumID string) ([]models.Track, error) {
 	endpoint := fmt.Sprintf("/v2/albums/%s?include=items&countryCode=%s", albumID, c.config.Tidal.CountryCode)
@@ -16,37 +108,99 @@ func (c *Client) GetAlbumTracks(ctx context.Context, albumID string) ([]models.T
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch album tracks: %w", err)
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
 
 	// Parse JSON:API format with included items
 	var apiResp struct {
-		Included []struct {
-			ID         string `json:"id"`
-			Type       string `json:"type"`
-			Attributes struct {
-				Title string `json:"title"`
-			} `json:"attributes"`
-		} `json:"included"`
+		Included []trackResource `json:"included"`
 	}
-	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err := decodeResponse(resp, &apiResp); err != nil {
+		return nil, err
 	}
 
 	// Convert included items to Track models
 	tracks := make([]models.Track, 0)
 	for _, item := range apiResp.Included {
 		if item.Type == "tracks" {
-			tracks = append(tracks, models.Track{
-				ID:    item.ID,
-				Title: item.Attributes.Title,
-			})
+			track := item.toModel(nil)
+			track.AlbumID = albumID
+			tracks = append(tracks, track)
 		}
 	}
 
 	return tracks, nil
 }
+
+// GetTracks retrieves the given tracks in batches, together with their
+// artists. Tracks that are not available in the configured country are
+// missing from the result.
+func (c *Client) GetTracks(ctx context.Context, trackIDs []string) ([]models.Track, error) {
+	tracks := make([]models.Track, 0, len(trackIDs))
+	for i := 0; i < len(trackIDs); i += MaxItemsPerRequest {
+		end := min(i+MaxItemsPerRequest, len(trackIDs))
+
+		query := url.Values{}
+		query.Set("countryCode", c.config.Tidal.CountryCode)
+		query.Set("include", "artists")
+		for _, trackID := range trackIDs[i:end] {
+			query.Add("filter[id]", trackID)
+		}
+
+		resp, err := c.get(ctx, "/v2/tracks?"+query.Encode())
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch tracks: %w", err)
+		}
+
+		var apiResp struct {
+			Data     []trackResource `json:"data"`
+			Included []albumResource `json:"included"`
+		}
+		if err := decodeResponse(resp, &apiResp); err != nil {
+			return nil, err
+		}
+
+		artistNames := make(map[string]string)
+		for _, item := range apiResp.Included {
+			if item.Type == "artists" {
+				artistNames[item.ID] = item.Attributes.Name
+			}
+		}
+
+		for _, item := range apiResp.Data {
+			tracks = append(tracks, item.toModel(artistNames))
+		}
+	}
+
+	return tracks, nil
+}
+
+// AddTracksToFavorites adds the given tracks to the user's favorite tracks.
+func (c *Client) AddTracksToFavorites(ctx context.Context, trackIDs []string) error {
+	userID, err := c.GetUserID(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get user ID: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("/v2/userCollections/%s/relationships/tracks?countryCode=%s", userID, c.config.Tidal.CountryCode)
+	for i := 0; i < len(trackIDs); i += MaxItemsPerRequest {
+		end := min(i+MaxItemsPerRequest, len(trackIDs))
+
+		// Convert track IDs to JSON:API format
+		data := make([]map[string]interface{}, 0, end-i)
+		for _, trackID := range trackIDs[i:end] {
+			data = append(data, map[string]interface{}{
+				"type": "tracks",
+				"id":   trackID,
+			})
+		}
+
+		resp, err := c.post(ctx, endpoint, map[string]interface{}{"data": data})
+		if err != nil {
+			return fmt.Errorf("failed to add tracks to favorites: %w", err)
+		}
+		if err := decodeResponse(resp, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+// Package backup reads and writes playlist backups as JSON files.
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SchemaVersion is the version of the backup file format.
+const SchemaVersion = 1
+
+// Playlist is the backup of a single playlist.
+type Playlist struct {
+	SchemaVersion int       `json:"schema_version"`
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description,omitempty"`
+	Created       time.Time `json:"created,omitempty"`
+	LastUpdated   time.Time `json:"last_updated,omitempty"`
+	ImageURL      string    `json:"image_url,omitempty"`
+	Tracks        []Track   `json:"tracks"`
+}
+
+// Track is a track of a backed up playlist.
+type Track struct {
+	ID    string `json:"id"`
+	ISRC  string `json:"isrc,omitempty"`
+	Title string `json:"title,omitempty"`
+}
+
+// unsafeChars matches everything that shouldn't be part of a file name.
+var unsafeChars = regexp.MustCompile(`[^\w\-. ]+`)
+
+// FileName returns the name of the backup file of a playlist. The ID keeps
+// playlists with the same name apart.
+func FileName(p Playlist) string {
+	name := strings.TrimSpace(unsafeChars.ReplaceAllString(p.Name, "_"))
+	if name == "" {
+		name = "playlist"
+	}
+	return fmt.Sprintf("%s-%s.json", name, p.ID)
+}
+
+// Write stores the playlist as JSON file in dir and returns the file path.
+func Write(dir string, p Playlist) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	p.SchemaVersion = SchemaVersion
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, FileName(p))
+	return path, os.WriteFile(path, data, 0644)
+}
+
+// ReadDir reads all playlist backups in dir, sorted by file name.
+func ReadDir(dir string) ([]Playlist, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no backup files found in %s", dir)
+	}
+
+	playlists := make([]Playlist, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var p Playlist
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if p.SchemaVersion > SchemaVersion {
+			return nil, fmt.Errorf("%s was written by a newer version (schema %d)", path, p.SchemaVersion)
+		}
+		playlists = append(playlists, p)
+	}
+
+	return playlists, nil
+}
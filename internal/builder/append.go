@@ -0,0 +1,122 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aligator/tidal-playlist/internal/models"
+	"github.com/aligator/tidal-playlist/internal/state"
+)
+
+// AppendNewFavorites appends a track of every artist added to the favorites
+// since the last run to the playlist, creating it if needed. The first run
+// only takes a snapshot of the current favorites.
+func (b *Builder) AppendNewFavorites(ctx context.Context, playlistName string, dryRun bool) error {
+	playlistName = b.config.Playlist.NamePrefix + playlistName + b.config.Playlist.NameSuffix
+
+	fmt.Print("Fetching favorite artists...\n\n")
+	artists, err := b.fetchFavorites(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch favorite artists: %w", err)
+	}
+
+	statePath := state.DefaultPath()
+	st, err := state.Load(statePath)
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	previous, ok := st.FavoriteSnapshots[playlistName]
+	if !ok {
+		fmt.Printf("No snapshot of your favorites for '%s' yet, new favorite artists are appended from the next run on\n", playlistName)
+		if dryRun {
+			return nil
+		}
+		return saveSnapshot(st, statePath, playlistName, artists)
+	}
+
+	known := make(map[string]bool, len(previous))
+	for _, id := range previous {
+		known[id] = true
+	}
+
+	var added []models.ArtistID
+	for _, artist := range artists {
+		if !known[artist.ID] {
+			added = append(added, artist)
+		}
+	}
+	fmt.Printf("Found %d new favorite artists\n", len(added))
+
+	if b.needsArtistDetails() {
+		if err := b.loadArtistDetails(ctx, added); err != nil {
+			return fmt.Errorf("failed to load artist details: %w", err)
+		}
+	}
+	added = b.FilterArtists(added)
+	if len(added) == 0 {
+		fmt.Println("Nothing to append")
+		if dryRun {
+			return nil
+		}
+		return saveSnapshot(st, statePath, playlistName, artists)
+	}
+
+	fmt.Println("\nCollecting tracks from new artists...")
+	collected, err := b.CollectTracks(ctx, added)
+	if err != nil {
+		return fmt.Errorf("failed to collect tracks: %w", err)
+	}
+
+	var trackIDs []string
+	for _, track := range collected {
+		if track != nil {
+			trackIDs = append(trackIDs, track.ID)
+		}
+	}
+
+	if dryRun {
+		fmt.Printf("\nWould append %d tracks to '%s'\n", len(trackIDs), playlistName)
+		return nil
+	}
+
+	if len(trackIDs) > 0 {
+		playlist, err := b.client.FindPlaylistByName(ctx, playlistName)
+		if err != nil {
+			return fmt.Errorf("failed to find playlist: %w", err)
+		}
+		if playlist == nil {
+			fmt.Printf("Creating new playlist '%s'...\n", playlistName)
+			playlist, err = b.client.CreatePlaylist(ctx, playlistName, GeneratorMarker)
+			if err != nil {
+				return fmt.Errorf("failed to create playlist: %w", err)
+			}
+		}
+
+		if err := b.client.AddTracksToPlaylist(ctx, playlist.GetID(), trackIDs); err != nil {
+			return err
+		}
+		fmt.Printf("\n✓ Appended %d tracks to '%s'\n", len(trackIDs), playlistName)
+		fmt.Printf("  %s\n", playlist.URL())
+	}
+
+	return saveSnapshot(st, statePath, playlistName, artists)
+}
+
+// saveSnapshot stores the favorite artists as snapshot for the playlist.
+func saveSnapshot(st *state.State, path, playlistName string, artists []models.ArtistID) error {
+	ids := make([]string, len(artists))
+	for i, artist := range artists {
+		ids[i] = artist.ID
+	}
+
+	if st.FavoriteSnapshots == nil {
+		st.FavoriteSnapshots = make(map[string][]string)
+	}
+	st.FavoriteSnapshots[playlistName] = ids
+
+	if err := st.Save(path); err != nil {
+		return fmt.Errorf("failed to save favorites snapshot: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package builder
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/aligator/tidal-playlist/internal/models"
+)
+
+var (
+	// versionKeyword matches the words marking the parts of a title that
+	// only name a release version or guest artists, not a different song.
+	// The short guest artist tags only match as whole words, so that e.g.
+	// "(Feather Remix)" is kept.
+	versionKeyword = regexp.MustCompile(`\b(remaster|deluxe|edition|bonus|anniversary|expanded|featuring)|\b(feat|ft)\b`)
+	// bracketedPart matches "(...)" and "[...]" parts of a title.
+	bracketedPart = regexp.MustCompile(`\s*[(\[][^)\]]*[)\]]`)
+	// dashSuffix matches a " - ..." suffix like in "Song - Remastered 2011".
+	dashSuffix = regexp.MustCompile(`\s+-\s+.*$`)
+	// featSuffix matches guest artists named outside of brackets. The short
+	// tags need their dot there, as e.g. "No Small Feat Tonight" is a title.
+	featSuffix = regexp.MustCompile(`\s+(feat\.|ft\.|featuring)\s+.*$`)
+	spaces     = regexp.MustCompile(`\s+`)
+)
+
+// normalizeTitle strips version and guest artist tags from a title, so that
+// e.g. "Song (Remastered)", "Song [Deluxe Edition]", "Song - Remastered 2011"
+// and "Song feat. Someone" all become "song".
+func normalizeTitle(title string) string {
+	title = strings.ToLower(title)
+
+	title = bracketedPart.ReplaceAllStringFunc(title, func(part string) string {
+		if versionKeyword.MatchString(part) {
+			return ""
+		}
+		return part
+	})
+	if suffix := dashSuffix.FindString(title); versionKeyword.MatchString(suffix) {
+		title = strings.TrimSuffix(title, suffix)
+	}
+	title = featSuffix.ReplaceAllString(title, "")
+
+	return strings.TrimSpace(spaces.ReplaceAllString(title, " "))
+}
+
+// dedupKey returns the key two tracks are considered the same song by.
+func (b *Builder) dedupKey(track models.Track) string {
+	// Without a known artist, the title alone is too weak to go by.
+	if b.config.Playlist.DedupBy == "title_artist" && track.ArtistID != "" {
+		return normalizeTitle(track.Title) + "\x00" + track.ArtistID
+	}
+	return track.ID
+}
+
+// dropDuplicates empties the slots of tracks that were already collected,
+// in this or an earlier call, so that they are filled like missing tracks.
+func (b *Builder) dropDuplicates(tracks []*models.Track) {
+	if b.config.Playlist.AllowDuplicates {
+		return
+	}
+
+	if b.collected == nil {
+		b.collected = make(map[string]bool)
+	}
+
+	for i, track := range tracks {
+		if track == nil {
+			continue
+		}
+
+		if b.existingTracks[track.ID] {
+			b.explainSkip("mode", "skipped track '%s' (already in the playlist)", track.Title)
+			tracks[i] = nil
+			continue
+		}
+
+		key := b.dedupKey(*track)
+		if b.collected[key] {
+			b.explainSkip("dedup_by", "skipped track '%s' (same song already selected)", track.Title)
+			tracks[i] = nil
+			continue
+		}
+		b.collected[key] = true
+	}
+}
+
+// uniqueTracks removes tracks whose ID already appeared earlier in tracks.
+func uniqueTracks(tracks []models.Track) []models.Track {
+	seen := make(map[string]bool, len(tracks))
+	result := tracks[:0]
+	for _, track := range tracks {
+		if seen[track.ID] {
+			continue
+		}
+		seen[track.ID] = true
+		result = append(result, track)
+	}
+	return result
+}
@@ -0,0 +1,42 @@
+package builder
+
+import "testing"
+
+func TestNormalizeTitle(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Song", "song"},
+		{"Song (Remastered)", "song"},
+		{"Song (2011 Remaster)", "song"},
+		{"Song [Deluxe Edition]", "song"},
+		{"Song - Remastered 2011", "song"},
+		{"Song (feat. Someone)", "song"},
+		{"Song (Feat Someone)", "song"},
+		{"Song [ft. Someone]", "song"},
+		{"Song (featuring Someone)", "song"},
+		{"Song feat. Someone", "song"},
+		{"Song ft. Someone", "song"},
+		{"Song featuring Someone", "song"},
+		// Words merely starting like a guest artist tag name another song.
+		{"Song (Feather Remix)", "song (feather remix)"},
+		{"Song (Featherweight Edit)", "song (featherweight edit)"},
+		{"Song - Feats of Strength", "song - feats of strength"},
+		{"Song (Left Behind Mix)", "song (left behind mix)"},
+		{"Song (Expedition Mix)", "song (expedition mix)"},
+		{"Song (Live)", "song (live)"},
+		{"Song - Acoustic", "song - acoustic"},
+		{"Feather", "feather"},
+		// Without a dot "feat" outside of brackets is part of the title.
+		{"No Small Feat Tonight", "no small feat tonight"},
+		{"A Feat of Clay", "a feat of clay"},
+		{"Song Feat Someone", "song feat someone"},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeTitle(tt.title); got != tt.want {
+			t.Errorf("normalizeTitle(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/aligator/tidal-playlist/internal/api"
+)
+
+// Estimate is the expected cost of building a playlist.
+type Estimate struct {
+	Artists  int // favorite artists left after filtering
+	Slots    int // tracks to collect, including spares
+	Requests int
+	Duration time.Duration // the least time the requests take at the configured rate
+}
+
+// Estimate fetches the favorite artists and estimates how many requests
+// building the playlist takes, without collecting any tracks. Tracks that
+// need to be replaced and retried requests aren't accounted for.
+func (b *Builder) Estimate(ctx context.Context, dryRun bool) (*Estimate, error) {
+	if b.config.Playlist.Source != "favorites" {
+		return nil, fmt.Errorf("estimates are only supported with source 'favorites'")
+	}
+
+	// Fetching the favorites and their details is done for real, so count
+	// the requests actually sent.
+	before := b.requestsSent()
+	fmt.Print("Fetching favorite artists...\n\n")
+	artists, err := b.fetchFavorites(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch favorite artists: %w", err)
+	}
+	if b.needsArtistDetails() {
+		if err := b.loadArtistDetails(ctx, artists); err != nil {
+			return nil, fmt.Errorf("failed to load artist details: %w", err)
+		}
+	}
+	requests := b.requestsSent() - before
+
+	artists = b.FilterArtists(artists)
+	if len(artists) == 0 {
+		return nil, fmt.Errorf("no artists remaining after filtering")
+	}
+
+	count := b.config.Playlist.Count
+	if target := b.config.Playlist.TargetDuration; target > 0 {
+		count = int(target/averageTrackDuration) + 1
+	}
+	slots := count + (count*b.config.Playlist.SparePercent+99)/100
+	if b.config.Playlist.ArtistSelection == "per_artist" {
+		slots = min(slots, len(artists)*b.config.Playlist.TracksPerArtist)
+	}
+
+	// The random strategy stops collecting once playlist.max_albums_fetched
+	// albums were fetched.
+	collected := slots
+	if limit := b.config.Playlist.MaxAlbumsFetched; limit > 0 && b.config.Playlist.TrackStrategy != "artist_top" {
+		collected = min(collected, limit)
+	}
+
+	// The albums or top tracks are fetched once per artist.
+	distinct := float64(min(collected, len(artists)))
+	if b.config.Playlist.ArtistSelection == "uniform" {
+		n := float64(len(artists))
+		distinct = n * (1 - math.Pow(1-1/n, float64(collected)))
+	}
+	requests += int(math.Ceil(distinct))
+
+	// Every random track needs its artist and the tracks of an album.
+	if b.config.Playlist.TrackStrategy != "artist_top" {
+		requests += 2 * collected
+	}
+
+	if b.config.Playlist.VerifyAvailability {
+		requests += batches(count)
+	}
+
+	// Looking up, creating and filling the playlist.
+	if !dryRun {
+		requests += 2 + batches(count)
+	}
+
+	return &Estimate{
+		Artists:  len(artists),
+		Slots:    slots,
+		Requests: requests,
+		Duration: time.Duration(float64(requests) / b.config.Tidal.RequestsPerSecond * float64(time.Second)),
+	}, nil
+}
+
+// requestsSent returns the number of requests sent by the clients of the
+// builder so far.
+func (b *Builder) requestsSent() int {
+	sent := b.client.Stats().Requests
+	for _, source := range b.favoritesSources {
+		sent += source.client.Stats().Requests
+	}
+	return sent
+}
+
+// batches returns the number of requests needed for n items.
+func batches(n int) int {
+	return (n + api.MaxItemsPerRequest - 1) / api.MaxItemsPerRequest
+}
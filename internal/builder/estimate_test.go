@@ -0,0 +1,56 @@
+package builder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aligator/tidal-playlist/internal/api"
+)
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   int
+	}{
+		// Fetching the favorites takes 2 requests, then every one of the
+		// 5 artists gets its albums fetched once and each of the 10 tracks
+		// needs its artist and an album.
+		{name: "unlimited", want: 2 + 5 + 2*10},
+		{name: "album limit", config: "  max_albums_fetched: 3\n", want: 2 + 3 + 2*3},
+		{name: "album limit above the slots", config: "  max_albums_fetched: 50\n", want: 2 + 5 + 2*10},
+		{name: "album limit with top tracks", config: "  max_albums_fetched: 3\n  track_strategy: artist_top\n", want: 2 + 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBuilder(t, newFakeTidal(5, 2, 3), "playlist:\n  count: 10\n  artist_selection: even\n"+tt.config)
+
+			estimate, err := b.Estimate(context.Background(), true)
+			if err != nil {
+				t.Fatalf("Estimate failed: %v", err)
+			}
+			if estimate.Requests != tt.want {
+				t.Errorf("estimated %d requests, want %d", estimate.Requests, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatches(t *testing.T) {
+	tests := []struct {
+		items int
+		want  int
+	}{
+		{0, 0},
+		{1, 1},
+		{api.MaxItemsPerRequest, 1},
+		{api.MaxItemsPerRequest + 1, 2},
+		{5 * api.MaxItemsPerRequest, 5},
+	}
+	for _, tt := range tests {
+		if got := batches(tt.items); got != tt.want {
+			t.Errorf("batches(%d) = %d, want %d", tt.items, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,388 @@
+package builder
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/aligator/tidal-playlist/internal/config"
+	"github.com/aligator/tidal-playlist/internal/models"
+)
+
+// Filter decides which artists, albums and tracks are kept. A filter only
+// looks at the kind of items it is about and keeps everything else. Next to
+// the decision, the reason for a rejection is returned, e.g. "explicit=true".
+type Filter interface {
+	// Name is the rule rejections are reported under, the same as the
+	// name of the filter in filters.pipeline.
+	Name() string
+	KeepArtist(artist models.ArtistID) (bool, string)
+	KeepAlbum(album models.Album) (bool, string)
+	KeepTrack(track models.Track) (bool, string)
+}
+
+// keepAll keeps everything, filters embed it and override the methods for
+// the kind of items they are about.
+type keepAll struct{}
+
+func (keepAll) KeepArtist(models.ArtistID) (bool, string) { return true, "" }
+func (keepAll) KeepAlbum(models.Album) (bool, string)     { return true, "" }
+func (keepAll) KeepTrack(models.Track) (bool, string)     { return true, "" }
+
+// relaxableFilters are skipped for artists that would have no albums left
+// otherwise, with filters.relax_for_empty_artists enabled.
+var relaxableFilters = map[string]bool{
+	"exclude_singles": true,
+	"labels":          true,
+	"exclude_labels":  true,
+}
+
+// newFilterPipeline creates the filters enabled in the config, in the order
+// of filters.pipeline or the default order of config.FilterNames.
+// artistNames, excludedTracks and popularity are filled later on, once the
+// excluded playlists and the artists are loaded.
+func newFilterPipeline(filters config.FiltersConfig, artistNames map[string]string, excludedTracks map[string]bool, popularity map[string]float64) []Filter {
+	order := filters.Pipeline
+	if len(order) == 0 {
+		order = config.FilterNames
+	}
+
+	// Missing label data is only noted once per build, by either label filter.
+	labelDataNoted := new(bool)
+
+	var pipeline []Filter
+	for _, name := range order {
+		var filter Filter
+		switch name {
+		case "artist_roles":
+			if len(filters.ArtistRoles) > 0 {
+				filter = rolesFilter{roles: filters.ArtistRoles}
+			}
+		case "whitelist":
+			if len(filters.Whitelist) > 0 {
+				filter = listFilter{name: name, artists: lowerSet(filters.Whitelist), names: artistNames, matchBy: filters.MatchBy, keepListed: true}
+			}
+		case "blacklist":
+			// The whitelist takes precedence over the blacklist.
+			if len(filters.Blacklist) > 0 && len(filters.Whitelist) == 0 {
+				filter = listFilter{name: name, artists: lowerSet(filters.Blacklist), names: artistNames, matchBy: filters.MatchBy}
+			}
+		case "exclude_explicit_albums":
+			if filters.ExcludeExplicitAlbums {
+				filter = explicitAlbumsFilter{}
+			}
+		case "exclude_singles":
+			if filters.ExcludeSingles {
+				filter = singlesFilter{}
+			}
+		case "labels":
+			if len(filters.Labels) > 0 {
+				filter = labelFilter{name: name, labels: filters.Labels, keepMatches: true, missingNoted: labelDataNoted}
+			}
+		case "exclude_labels":
+			if len(filters.ExcludeLabels) > 0 {
+				filter = labelFilter{name: name, labels: filters.ExcludeLabels, missingNoted: labelDataNoted}
+			}
+		case "exclude_explicit":
+			if filters.ExcludeExplicit {
+				filter = explicitTracksFilter{}
+			}
+		case "min_quality":
+			if filters.MinQuality != "" {
+				filter = qualityFilter{minQuality: filters.MinQuality}
+			}
+		case "exclude_in_playlists":
+			if len(filters.ExcludeInPlaylists) > 0 {
+				filter = excludedTracksFilter{tracks: excludedTracks}
+			}
+		case "min_artist_popularity":
+			if filters.MinArtistPopularity > 0 {
+				filter = popularityFilter{minPopularity: filters.MinArtistPopularity, popularity: popularity}
+			}
+		}
+
+		if filter != nil {
+			pipeline = append(pipeline, filter)
+		}
+	}
+	return pipeline
+}
+
+func lowerSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[strings.ToLower(value)] = true
+	}
+	return set
+}
+
+// rolesFilter keeps artists with one of the roles. Artists without role
+// information are kept.
+type rolesFilter struct {
+	keepAll
+	roles []string
+}
+
+func (rolesFilter) Name() string { return "artist_roles" }
+
+func (f rolesFilter) KeepArtist(artist models.ArtistID) (bool, string) {
+	if len(artist.Roles) == 0 {
+		return true, ""
+	}
+
+	hasRole := slices.ContainsFunc(artist.Roles, func(role string) bool {
+		return slices.ContainsFunc(f.roles, func(wanted string) bool {
+			return strings.EqualFold(wanted, role)
+		})
+	})
+	return hasRole, "roles=" + strings.Join(artist.Roles, ",")
+}
+
+// listFilter keeps either only the listed artists (whitelist) or all but
+// the listed artists (blacklist). Entries match the ID or the name of an
+// artist, according to filters.match_by.
+type listFilter struct {
+	keepAll
+	name       string
+	artists    map[string]bool
+	names      map[string]string // lowercased names by artist ID
+	matchBy    string
+	keepListed bool
+}
+
+func (f listFilter) Name() string { return f.name }
+
+func (f listFilter) KeepArtist(artist models.ArtistID) (bool, string) {
+	listed := false
+	if f.matchBy != "name" {
+		listed = f.artists[strings.ToLower(artist.ID)]
+	}
+	if name := f.names[artist.ID]; !listed && f.matchBy != "id" && name != "" {
+		listed = f.artists[name]
+	}
+	if f.keepListed {
+		return listed, "not in whitelist"
+	}
+	return !listed, "in blacklist"
+}
+
+// explicitAlbumsFilter rejects explicit albums.
+type explicitAlbumsFilter struct{ keepAll }
+
+func (explicitAlbumsFilter) Name() string { return "exclude_explicit_albums" }
+
+func (explicitAlbumsFilter) KeepAlbum(album models.Album) (bool, string) {
+	return !album.Explicit, "explicit=true, exclude_explicit_albums=true"
+}
+
+// singlesFilter rejects albums of type SINGLE.
+type singlesFilter struct{ keepAll }
+
+func (singlesFilter) Name() string { return "exclude_singles" }
+
+func (singlesFilter) KeepAlbum(album models.Album) (bool, string) {
+	return !strings.EqualFold(album.Type, "SINGLE"), fmt.Sprintf("type=%s, exclude_singles=true", album.Type)
+}
+
+// labelFilter keeps either only albums of the labels or all but them.
+// The label is matched against the copyright line of the album, as the API
+// has no dedicated label attribute. Albums without that data are kept.
+type labelFilter struct {
+	keepAll
+	name        string
+	labels      []string
+	keepMatches bool
+
+	// missingNoted is set once albums without label data were noted.
+	missingNoted *bool
+}
+
+func (f labelFilter) Name() string { return f.name }
+
+func (f labelFilter) KeepAlbum(album models.Album) (bool, string) {
+	if album.Copyright == "" {
+		if !*f.missingNoted {
+			fmt.Printf("Note: no label data for album '%s', albums without label data are kept\n", album.DisplayTitle())
+			*f.missingNoted = true
+		}
+		return true, ""
+	}
+
+	if f.keepMatches {
+		return matchesAnyLabel(album.Copyright, f.labels), fmt.Sprintf("copyright=%q matches none of labels", album.Copyright)
+	}
+	return !matchesAnyLabel(album.Copyright, f.labels), fmt.Sprintf("copyright=%q matches exclude_labels", album.Copyright)
+}
+
+// matchesAnyLabel reports whether the copyright line mentions any of the labels.
+func matchesAnyLabel(copyright string, labels []string) bool {
+	copyright = strings.ToLower(copyright)
+	for _, label := range labels {
+		if strings.Contains(copyright, strings.ToLower(label)) {
+			return true
+		}
+	}
+	return false
+}
+
+// explicitTracksFilter rejects explicit tracks.
+type explicitTracksFilter struct{ keepAll }
+
+func (explicitTracksFilter) Name() string { return "exclude_explicit" }
+
+func (explicitTracksFilter) KeepTrack(track models.Track) (bool, string) {
+	return !track.Explicit, "explicit=true, exclude_explicit=true"
+}
+
+// qualityFilter rejects tracks below the minimum audio quality. Tracks of
+// unknown quality are kept.
+type qualityFilter struct {
+	keepAll
+	minQuality string
+}
+
+func (qualityFilter) Name() string { return "min_quality" }
+
+func (f qualityFilter) KeepTrack(track models.Track) (bool, string) {
+	keep := track.Quality == "" || models.QualityRank(track.Quality) >= models.QualityRank(f.minQuality)
+	return keep, fmt.Sprintf("quality=%s, min_quality=%s", track.Quality, f.minQuality)
+}
+
+// excludedTracksFilter rejects the tracks of the excluded playlists.
+type excludedTracksFilter struct {
+	keepAll
+	tracks map[string]bool
+}
+
+func (excludedTracksFilter) Name() string { return "exclude_in_playlists" }
+
+func (f excludedTracksFilter) KeepTrack(track models.Track) (bool, string) {
+	return !f.tracks[track.ID], "already in an excluded playlist"
+}
+
+// popularityFilter rejects artists below the minimum popularity. Artists
+// of unknown popularity are kept.
+type popularityFilter struct {
+	keepAll
+	minPopularity float64
+	popularity    map[string]float64
+}
+
+func (popularityFilter) Name() string { return "min_artist_popularity" }
+
+func (f popularityFilter) KeepArtist(artist models.ArtistID) (bool, string) {
+	popularity, ok := f.popularity[artist.ID]
+	if !ok || popularity == 0 {
+		return true, ""
+	}
+	return popularity >= f.minPopularity, fmt.Sprintf("popularity=%.2f, min_artist_popularity=%.2f", popularity, f.minPopularity)
+}
+
+// FilterArtists applies the artist filters of the pipeline to artists.
+func (b *Builder) FilterArtists(artists []models.ArtistID) []models.ArtistID {
+	var filtered []models.ArtistID
+	for _, artist := range artists {
+		if b.keep(func(filter Filter) (bool, string) { return filter.KeepArtist(artist) }, "skipped artist %s", artist.ID) {
+			filtered = append(filtered, artist)
+		}
+	}
+
+	if len(b.config.Filters.ArtistRoles) > 0 {
+		unknown := 0
+		for _, artist := range artists {
+			if len(artist.Roles) == 0 {
+				unknown++
+			}
+		}
+		if unknown > 0 {
+			fmt.Printf("Note: no role data for %d artists, keeping them\n", unknown)
+		}
+	}
+
+	return filtered
+}
+
+// FilterAlbums applies the album filters of the pipeline to albums.
+func (b *Builder) FilterAlbums(albums []models.Album) []models.Album {
+	return b.filterAlbums(albums, false)
+}
+
+// filterAlbums applies the album filters of the pipeline to albums. If
+// relaxed is set, the relaxable filters like the type and label filters are
+// skipped.
+func (b *Builder) filterAlbums(albums []models.Album, relaxed bool) []models.Album {
+	var filtered []models.Album
+	for _, album := range albums {
+		keep := func(filter Filter) (bool, string) {
+			if relaxed && relaxableFilters[filter.Name()] {
+				return true, ""
+			}
+			return filter.KeepAlbum(album)
+		}
+		if b.keep(keep, "skipped album '%s'", album.DisplayTitle()) {
+			filtered = append(filtered, album)
+		}
+	}
+	return filtered
+}
+
+// FilterTracks applies the track filters of the pipeline to tracks.
+func (b *Builder) FilterTracks(tracks []models.Track) []models.Track {
+	var filtered []models.Track
+	for _, track := range tracks {
+		if b.keep(func(filter Filter) (bool, string) { return filter.KeepTrack(track) }, "skipped track '%s'", track.Title) {
+			filtered = append(filtered, track)
+		}
+	}
+	return filtered
+}
+
+// keep runs an item through the pipeline and reports whether all filters
+// keep it. The first rejection is recorded with the item described by
+// format and args.
+func (b *Builder) keep(decide func(Filter) (bool, string), format string, args ...any) bool {
+	rule, reason, rejected := b.firstRejection(decide)
+	if rejected {
+		b.explainSkip(rule, format+" (%s)", append(args, reason)...)
+	}
+	return !rejected
+}
+
+// firstRejection runs an item through the pipeline and returns the rule and
+// reason of the first filter rejecting it, if any.
+func (b *Builder) firstRejection(decide func(Filter) (bool, string)) (rule, reason string, rejected bool) {
+	for _, filter := range b.filters {
+		if ok, reason := decide(filter); !ok {
+			return filter.Name(), reason, true
+		}
+	}
+	return "", "", false
+}
+
+// explainSkip records that a filter rule rejected something and, with
+// explain_skips enabled, prints why.
+func (b *Builder) explainSkip(rule, format string, args ...any) {
+	if b.skips == nil {
+		b.skips = make(map[string]int)
+	}
+	b.skips[rule]++
+
+	if b.config.Filters.ExplainSkips {
+		fmt.Printf("  "+format+"\n", args...)
+	}
+}
+
+// printSkipSummary prints how often each filter rule rejected something.
+func (b *Builder) printSkipSummary() {
+	fmt.Println("\nSkipped by filters:")
+	if len(b.skips) == 0 {
+		fmt.Println("  nothing")
+		return
+	}
+
+	rules := slices.Sorted(maps.Keys(b.skips))
+	for _, rule := range rules {
+		fmt.Printf("  %-24s %d\n", rule, b.skips[rule])
+	}
+}
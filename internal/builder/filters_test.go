@@ -0,0 +1,65 @@
+package builder
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aligator/tidal-playlist/internal/config"
+	"github.com/aligator/tidal-playlist/internal/models"
+)
+
+// captureStdout returns what f prints to stdout.
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+
+	f()
+	w.Close()
+	return <-done
+}
+
+func TestLabelFilterNotesMissingDataOnce(t *testing.T) {
+	filters := config.FiltersConfig{
+		Labels:        []string{"Blue Note"},
+		ExcludeLabels: []string{"Some Label"},
+	}
+	pipeline := newFilterPipeline(filters, nil, nil, nil)
+
+	albums := []models.Album{
+		{ID: "1", Title: "First"},
+		{ID: "2", Title: "Second"},
+		{ID: "3", Title: "Third", Copyright: "(P) 1959 Blue Note Records"},
+		{ID: "4", Title: "Fourth"},
+	}
+	output := captureStdout(t, func() {
+		for _, album := range albums {
+			for _, filter := range pipeline {
+				if keep, _ := filter.KeepAlbum(album); !keep {
+					t.Errorf("%s rejected album %s", filter.Name(), album.ID)
+				}
+			}
+		}
+	})
+
+	if notes := strings.Count(output, "no label data"); notes != 1 {
+		t.Errorf("missing label data noted %d times, want once:\n%s", notes, output)
+	}
+	if !strings.Contains(output, "'First'") {
+		t.Errorf("the note doesn't name the first album:\n%s", output)
+	}
+}
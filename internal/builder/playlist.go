@@ -2,94 +2,532 @@ package builder
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"maps"
 	"math/rand"
+	"regexp"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aligator/tidal-playlist/internal/api"
 	"github.com/aligator/tidal-playlist/internal/config"
+	"github.com/aligator/tidal-playlist/internal/export"
 	"github.com/aligator/tidal-playlist/internal/models"
+	"github.com/aligator/tidal-playlist/internal/recipe"
+	"github.com/aligator/tidal-playlist/internal/state"
 )
 
+// GeneratorMarker is put into the description of generated playlists, so they
+// can be told apart from playlists created by the user.
+const GeneratorMarker = "Generated by tidal-playlist"
+
+// historyDateLayout is the date appended to the name of playlists with a
+// history.
+const historyDateLayout = "2006-01-02"
+
+// historySuffix matches what follows the base name in the name of a history
+// playlist: the date and, if the playlist was split, the number of the part.
+var historySuffix = regexp.MustCompile(`^ (\d{4}-\d{2}-\d{2})(?: \(\d+\))?$`)
+
 // selectRandomItems returns from the source items a random selection.
 // One item may be selected multiple times.
-func selectRandomItems[T any](count int, source []T) []T {
+func selectRandomItems[T any](rng *rand.Rand, count int, source []T) []T {
 	result := make([]T, count)
 	for i := 0; i < count; i++ {
-		randomRobert := rand.Intn(len(source))
+		randomRobert := rng.Intn(len(source))
 		result[i] = source[randomRobert]
 	}
 	return result
 }
 
+// ErrUnchanged is returned by BuildPlaylist if only_if_changed is set and the
+// favorite artists didn't change since the playlist was last built.
+var ErrUnchanged = errors.New("favorite artists unchanged since the last run")
+
 // Builder handles playlist generation logic.
 type Builder struct {
 	client *api.Client
 	config *config.Config
+
+	// rng is the source of every random decision, seeded with
+	// playlist.seed to make builds reproducible.
+	rng  *rand.Rand
+	seed int64
+
+	// selection picks the artists to collect tracks from.
+	selection SelectionStrategy
+
+	// filters is the filter pipeline applied to artists, albums and tracks.
+	filters []Filter
+
+	// excludedTracks contains the tracks of the playlists configured in
+	// filters.exclude_in_playlists.
+	excludedTracks map[string]bool
+
+	// popularity and artistNames cache the popularity and lowercased name
+	// of the artists, they are only loaded if the artist filters need them.
+	popularity  map[string]float64
+	artistNames map[string]string
+
+	// spares are extra tracks collected to replace dropped ones.
+	spares     []models.Track
+	sparesUsed int
+
+	// stateKey identifies the playlist in the state file, favoritesHash is
+	// the hash of the favorite artists of this run.
+	stateKey      string
+	favoritesHash string
+
+	// skips counts the filter rejections per rule.
+	skips map[string]int
+
+	// collected contains the dedup keys of all collected tracks.
+	collected map[string]bool
+
+	// appendTo is the existing playlist new tracks are appended to in
+	// append mode, existingTracks contains its tracks.
+	appendTo       *models.Playlist
+	existingTracks map[string]bool
+
+	// failedArtists are the artists that contributed no track to the build.
+	failedArtists []string
+
+	// albumTracks and artistTracks count the collected tracks per album
+	// and per artist.
+	albumTracks  map[string]int
+	artistTracks map[string]int
+
+	// albumsFetched counts the albums fetched over all collection passes
+	// of the build against playlist.max_albums_fetched, albumLimitHit is
+	// set once the limit was reached.
+	albumsFetched int
+	albumLimitHit bool
+
+	// deadline is when collecting has to stop to leave time for writing,
+	// timeBounded is set once it stopped because of it.
+	deadline    time.Time
+	timeBounded bool
+
+	// favoritesSources are the clients of the profiles whose favorites are
+	// merged. Without any, the favorites of client are used.
+	favoritesSources []favoritesSource
+
+	// fromRecipe replaces the selection with the tracks of a saved recipe,
+	// recipePath is where the selection of this build is saved to.
+	fromRecipe *recipe.Recipe
+	recipePath string
+
+	// exportPath is where the selected tracks are exported to as M3U.
+	exportPath string
+
+	// queuePath is the queue file the playlist is added to instead of
+	// writing it.
+	queuePath string
+}
+
+// favoritesSource is a profile contributing its favorite artists.
+type favoritesSource struct {
+	name   string
+	client *api.Client
 }
 
 // NewBuilder creates a new playlist builder.
 func NewBuilder(client *api.Client, cfg *config.Config) *Builder {
-	return &Builder{
-		client: client,
-		config: cfg,
+	seed := cfg.Playlist.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
 	}
+
+	excludedTracks := make(map[string]bool)
+	popularity := make(map[string]float64)
+	artistNames := make(map[string]string)
+	b := &Builder{
+		client:         client,
+		config:         cfg,
+		rng:            rand.New(rand.NewSource(seed)),
+		seed:           seed,
+		filters:        newFilterPipeline(cfg.Filters, artistNames, excludedTracks, popularity),
+		excludedTracks: excludedTracks,
+		popularity:     popularity,
+		artistNames:    artistNames,
+		albumTracks:    make(map[string]int),
+		artistTracks:   make(map[string]int),
+	}
+	b.selection = newSelectionStrategy(cfg.Playlist, b.tracksPerArtist)
+	return b
+}
+
+// AddFavoritesSource adds a profile whose favorite artists are merged into
+// the pool of artists.
+func (b *Builder) AddFavoritesSource(name string, client *api.Client) {
+	b.favoritesSources = append(b.favoritesSources, favoritesSource{name: name, client: client})
 }
 
-// FilterArtists applies whitelist and blacklist filters to artists.
-func (b *Builder) FilterArtists(artists []models.ArtistID) []models.ArtistID {
-	// If whitelist is set, only include artists in whitelist
-	if len(b.config.Filters.Whitelist) > 0 {
-		return b.filterByWhitelist(artists)
+// deadlineReserve is the share of the time budget kept for writing the
+// playlist after collecting stopped.
+const deadlineReserve = 5 // 1/5th
+
+// SetDeadline bounds the build: once the deadline approaches, collecting
+// stops and the playlist is written with the tracks gathered so far.
+func (b *Builder) SetDeadline(deadline time.Time) {
+	reserve := time.Until(deadline) / deadlineReserve
+	b.deadline = deadline.Add(-reserve)
+}
+
+// outOfTime reports whether collecting has to stop because of the deadline.
+func (b *Builder) outOfTime() bool {
+	if b.deadline.IsZero() || time.Now().Before(b.deadline) {
+		return false
 	}
 
-	// Otherwise apply blacklist
-	if len(b.config.Filters.Blacklist) > 0 {
-		return b.filterByBlacklist(artists)
+	if !b.timeBounded {
+		fmt.Println("Deadline approaching, building from the tracks collected so far")
+		b.timeBounded = true
 	}
+	return true
+}
+
+// ExportM3U makes the builder export the selected tracks as M3U file to path,
+// in dry-run mode as well.
+func (b *Builder) ExportM3U(path string) {
+	b.exportPath = path
+}
+
+// UseRecipe makes the builder write the tracks of the recipe instead of
+// selecting new ones.
+func (b *Builder) UseRecipe(r *recipe.Recipe) {
+	b.fromRecipe = r
+	b.seed = r.Seed
+}
 
-	// No filters, return all
-	return artists
+// SaveRecipe makes the builder save the selected tracks as recipe to path.
+func (b *Builder) SaveRecipe(path string) {
+	b.recipePath = path
 }
 
-// filterByWhitelist returns only artists in the whitelist.
-func (b *Builder) filterByWhitelist(artists []models.ArtistID) []models.ArtistID {
-	whitelist := make(map[string]bool)
-	for _, name := range b.config.Filters.Whitelist {
-		whitelist[strings.ToLower(name)] = true
+// fetchFavorites returns the favorite artists of all sources, without
+// duplicates.
+func (b *Builder) fetchFavorites(ctx context.Context) ([]models.ArtistID, error) {
+	if len(b.favoritesSources) == 0 {
+		return b.client.GetFavoriteArtists(ctx)
 	}
 
-	var filtered []models.ArtistID
-	for _, artist := range artists {
-		if whitelist[strings.ToLower(artist.ID)] {
-			filtered = append(filtered, artist)
+	seen := make(map[string]bool)
+	var artists []models.ArtistID
+	for _, source := range b.favoritesSources {
+		favorites, err := source.client.GetFavoriteArtists(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: %w", source.name, err)
 		}
+
+		added := 0
+		for _, artist := range favorites {
+			if seen[artist.ID] {
+				continue
+			}
+			seen[artist.ID] = true
+			artists = append(artists, artist)
+			added++
+		}
+		fmt.Printf("Profile %s: %d favorite artists, %d not in the pool yet\n", source.name, len(favorites), added)
 	}
 
-	return filtered
+	return artists, nil
 }
 
-// filterByBlacklist returns artists not in the blacklist.
-func (b *Builder) filterByBlacklist(artists []models.ArtistID) []models.ArtistID {
-	blacklist := make(map[string]bool)
-	for _, name := range b.config.Filters.Blacklist {
-		blacklist[strings.ToLower(name)] = true
+// loadExcludedTracks collects the tracks of all playlists listed in
+// filters.exclude_in_playlists. Entries are matched by name first and
+// treated as playlist ID otherwise.
+func (b *Builder) loadExcludedTracks(ctx context.Context) error {
+	for _, entry := range b.config.Filters.ExcludeInPlaylists {
+		playlists, err := b.client.FindAllPlaylistsByName(ctx, entry)
+		if err != nil {
+			return err
+		}
+
+		playlistIDs := []string{entry}
+		if len(playlists) > 0 {
+			playlistIDs = playlistIDs[:0]
+			for _, playlist := range playlists {
+				playlistIDs = append(playlistIDs, playlist.GetID())
+			}
+		}
+
+		for _, playlistID := range playlistIDs {
+			items, err := b.client.GetPlaylistItems(ctx, playlistID)
+			if err != nil {
+				return fmt.Errorf("failed to get tracks of playlist '%s': %w", entry, err)
+			}
+			for _, item := range items {
+				b.excludedTracks[item.ID] = true
+			}
+		}
 	}
 
-	var filtered []models.ArtistID
+	return nil
+}
+
+// loadArtistDetails fetches the popularity and name of all artists not
+// cached yet, in batches.
+func (b *Builder) loadArtistDetails(ctx context.Context, artists []models.ArtistID) error {
+	var missing []string
 	for _, artist := range artists {
-		if !blacklist[strings.ToLower(artist.ID)] {
-			filtered = append(filtered, artist)
+		if _, ok := b.artistNames[artist.ID]; !ok {
+			missing = append(missing, artist.ID)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	fmt.Printf("Fetching details of %d artists...\n", len(missing))
+	resolved, err := b.client.GetArtists(ctx, missing)
+	if err != nil {
+		return err
+	}
+	for _, artist := range resolved {
+		b.popularity[artist.ID] = artist.Attributes.Popularity
+		b.artistNames[artist.ID] = strings.ToLower(artist.Attributes.Name)
+	}
+
+	// Don't ask again for artists that weren't found.
+	for _, id := range missing {
+		if _, ok := b.artistNames[id]; !ok {
+			b.artistNames[id] = ""
+		}
+	}
+	return nil
+}
+
+// needsArtistDetails reports whether the artist filters or overrides need the
+// details loaded by loadArtistDetails.
+func (b *Builder) needsArtistDetails() bool {
+	if b.config.Filters.MinArtistPopularity > 0 {
+		return true
+	}
+
+	// The blacklist is ignored while a whitelist is set.
+	entries := b.config.Filters.Whitelist
+	if len(entries) == 0 {
+		entries = b.config.Filters.Blacklist
+	}
+
+	// Overrides keyed by name need the names of all artists.
+	for key := range b.config.Playlist.ArtistOverrides {
+		if !isNumericID(key) {
+			return true
 		}
 	}
 
-	return filtered
+	switch b.config.Filters.MatchBy {
+	case "name":
+		return len(entries) > 0
+	case "both":
+		// Lists of IDs only don't need any names.
+		return slices.ContainsFunc(entries, func(entry string) bool {
+			return !isNumericID(entry)
+		})
+	}
+	return false
 }
 
-// CollectTracks collects exactly totalTrackLimit tracks randomly.
-// Strategy: For each track slot, pick a random artist, random album, random track.
+// isNumericID reports whether s looks like a Tidal ID rather than a name.
+func isNumericID(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// CollectTracks collects one track for each of the given artists using the
+// configured track strategy. The track of artists[i] ends up at index i, the
+// artists may be reordered for that. Slots without a track are nil.
 func (b *Builder) CollectTracks(ctx context.Context, artists []models.ArtistID) ([]*models.Track, error) {
+	// Move the artists using top tracks to the front, so that each strategy
+	// collects a part of the slots and the tracks stay in order.
+	top := 0
+	for i := range artists {
+		if b.trackStrategy(artists[i].ID) == "artist_top" {
+			artists[top], artists[i] = artists[i], artists[top]
+			top++
+		}
+	}
+
+	tracks, err := b.collectTopTracks(ctx, artists[:top])
+	if err != nil {
+		return nil, err
+	}
+	randomTracks, err := b.collectRandomTracks(ctx, artists[top:])
+	if err != nil {
+		return nil, err
+	}
+	tracks = append(tracks, randomTracks...)
+
+	b.dropDuplicates(tracks)
+	for i, track := range tracks {
+		if track != nil {
+			b.artistTracks[artists[i].ID]++
+		}
+	}
+	return tracks, nil
+}
+
+// collectTopTracks picks for each artist slot a random track out of the
+// artist's top tracks.
+func (b *Builder) collectTopTracks(ctx context.Context, artists []models.ArtistID) ([]*models.Track, error) {
+	result := make([]*models.Track, len(artists))
+
+	topTracks := make(map[string][]models.Track)
+	for i, artistID := range artists {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if b.outOfTime() {
+			break
+		}
+
+		tracks, ok := topTracks[artistID.ID]
+		if !ok {
+			var err error
+			tracks, err = b.client.GetArtistTopTracks(ctx, artistID.ID, b.tracksPerArtist(artistID.ID))
+			if errors.Is(err, api.ErrAPIDown) {
+				return nil, err
+			}
+			if err != nil {
+				fmt.Printf("Warning: failed to get top tracks for %s: %v\n", artistID.ID, err)
+			}
+			topTracks[artistID.ID] = tracks
+		}
+
+		tracks = b.FilterTracks(tracks)
+		if len(tracks) == 0 {
+			continue
+		}
+
+		randomTrack := tracks[b.rng.Intn(len(tracks))]
+		randomTrack.ArtistID = artistID.ID
+		result[i] = &randomTrack
+		fmt.Printf("%s: %s\n", artistID.ID, randomTrack.Title)
+	}
+
+	return result, nil
+}
+
+// pickAlbum selects a random album, weighted according to the configured
+// album weighting and release date preference.
+func (b *Builder) pickAlbum(albums []models.Album) models.Album {
+	preferRecent := b.config.Playlist.PreferRecent
+	preferClassics := b.config.Playlist.PreferClassics
+	if b.config.Playlist.AlbumWeighting != "tracks" && !preferRecent && !preferClassics {
+		return albums[b.rng.Intn(len(albums))]
+	}
+
+	oldest, newest := 0, 0
+	for _, album := range albums {
+		if year := releaseYear(album); year > 0 {
+			if oldest == 0 || year < oldest {
+				oldest = year
+			}
+			newest = max(newest, year)
+		}
+	}
+
+	weight := func(album models.Album) int {
+		w := 1
+		if b.config.Playlist.AlbumWeighting == "tracks" {
+			// Albums with an unknown number of tracks count as one track.
+			w = max(album.NumberOfTracks, 1)
+		}
+
+		// Albums without a known release date are weighted like the
+		// least preferred ones.
+		if year := releaseYear(album); year > 0 {
+			switch {
+			case preferRecent:
+				w *= 1 + year - oldest
+			case preferClassics:
+				w *= 1 + newest - year
+			}
+		}
+		return w
+	}
+
+	total := 0
+	for _, album := range albums {
+		total += weight(album)
+	}
+
+	pick := b.rng.Intn(total)
+	for _, album := range albums {
+		pick -= weight(album)
+		if pick < 0 {
+			return album
+		}
+	}
+	return albums[len(albums)-1]
+}
+
+// albumsBelowLimit returns the albums that may still contribute a track
+// according to playlist.max_tracks_per_album.
+func (b *Builder) albumsBelowLimit(albums []models.Album) []models.Album {
+	limit := b.config.Playlist.MaxTracksPerAlbum
+	if limit == 0 {
+		return albums
+	}
+
+	var result []models.Album
+	for _, album := range albums {
+		if b.albumTracks[album.ID] < limit {
+			result = append(result, album)
+		}
+	}
+	return result
+}
+
+// leastUsedAlbums returns the albums that contributed the fewest tracks so
+// far, to spread the tracks of an artist over its albums.
+func (b *Builder) leastUsedAlbums(albums []models.Album) []models.Album {
+	fewest := -1
+	for _, album := range albums {
+		if fewest < 0 || b.albumTracks[album.ID] < fewest {
+			fewest = b.albumTracks[album.ID]
+		}
+	}
+
+	var result []models.Album
+	for _, album := range albums {
+		if b.albumTracks[album.ID] == fewest {
+			result = append(result, album)
+		}
+	}
+	return result
+}
+
+// releaseYear returns the year an album was released in, or 0 if unknown.
+func releaseYear(album models.Album) int {
+	if len(album.ReleaseDate) < 4 {
+		return 0
+	}
+	year, err := strconv.Atoi(album.ReleaseDate[:4])
+	if err != nil {
+		return 0
+	}
+	return year
+}
+
+// collectRandomTracks collects one track per artist slot randomly.
+// Strategy: For each track slot, pick a random artist, random album, random track.
+func (b *Builder) collectRandomTracks(ctx context.Context, artists []models.ArtistID) ([]*models.Track, error) {
 	result := make([]*models.Track, len(artists))
 
 	// Sort the artists so that the same artists are grouped and fetching its albums
@@ -101,9 +539,23 @@ func (b *Builder) CollectTracks(ctx context.Context, artists []models.ArtistID)
 	lastArtist := ""
 	lastAlbums := []models.Album{}
 	for i, artistId := range artists {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if b.albumBudgetSpent() {
+			break
+		}
+		if b.outOfTime() {
+			break
+		}
+
 		artist, err := b.client.GetArtist(ctx, artistId.ID)
+		if errors.Is(err, api.ErrAPIDown) {
+			return nil, err
+		}
 		if err != nil {
-			fmt.Printf("Warning: failed to get more information about the artist %s: %v\n", artist.ID, err)
+			fmt.Printf("Warning: failed to get more information about the artist %s: %v\n", artistId.ID, err)
 			artist = &models.Artist{
 				ID: artistId.ID,
 			}
@@ -112,24 +564,63 @@ func (b *Builder) CollectTracks(ctx context.Context, artists []models.ArtistID)
 		if lastArtist == "" || lastArtist != artist.ID {
 			fmt.Println(artist.Attributes.Name + " (" + artist.ID + ")")
 			albums, err := b.client.GetArtistAlbums(ctx, artist.ID, 100)
-			if err != nil || len(albums) == 0 {
+			if errors.Is(err, api.ErrAPIDown) {
+				return nil, err
+			}
+			if err != nil {
 				fmt.Printf("Warning: failed to get albums for %s: %v\n", artist.ID, err)
 				continue
 			}
+			if len(albums) == 0 {
+				switch b.config.Builder.OnMissingAlbums {
+				case "error":
+					return nil, fmt.Errorf("artist %s has no albums", artist.ID)
+				case "warn":
+					fmt.Printf("Warning: no albums found for %s\n", artist.ID)
+				}
+				continue
+			}
+
+			filtered := b.FilterAlbums(albums)
+			if len(filtered) == 0 && b.config.Filters.RelaxForEmptyArtists {
+				fmt.Printf("Note: the album filters leave nothing for %s, relaxing them for this artist\n", artist.ID)
+				filtered = b.filterAlbums(albums, true)
+			}
+			if len(filtered) == 0 {
+				fmt.Printf("Warning: no albums left for %s after filtering\n", artist.ID)
+				continue
+			}
+			albums = filtered
 
 			lastArtist = artist.ID
 			lastAlbums = albums
 		}
 
-		randomAlbum := lastAlbums[rand.Intn(len(lastAlbums))]
-		fmt.Printf("  %s - ", randomAlbum.Title)
+		albums := b.albumsBelowLimit(lastAlbums)
+		if len(albums) == 0 {
+			b.explainSkip("max_tracks_per_album", "skipped a track of %s (all albums reached the limit)", artist.ID)
+			continue
+		}
+		if b.config.Playlist.ArtistSelection == "per_artist" {
+			albums = b.leastUsedAlbums(albums)
+		}
+
+		randomAlbum := b.pickAlbum(albums)
+		fmt.Printf("  %s - ", randomAlbum.DisplayTitle())
 
 		// Get tracks from that album.
 		tracks, err := b.client.GetAlbumTracks(ctx, randomAlbum.ID)
+		b.albumsFetched++
+		if errors.Is(err, api.ErrAPIDown) {
+			return nil, err
+		}
+		tracks = b.FilterTracks(tracks)
 		if err == nil && len(tracks) > 0 {
 			// Pick random track.
-			randomTrack := tracks[rand.Intn(len(tracks))]
+			randomTrack := tracks[b.rng.Intn(len(tracks))]
+			randomTrack.ArtistID = artist.ID
 			result[i] = &randomTrack
+			b.albumTracks[randomAlbum.ID]++
 			fmt.Println(randomTrack.Title)
 		}
 	}
@@ -137,64 +628,492 @@ func (b *Builder) CollectTracks(ctx context.Context, artists []models.ArtistID)
 	return result, nil
 }
 
-// BuildPlaylist orchestrates the entire playlist generation process.
-func (b *Builder) BuildPlaylist(ctx context.Context, playlistName string, dryRun bool) error {
-	fmt.Println("Fetching favorite artists...\n")
-	artists, err := b.client.GetFavoriteArtists(ctx)
+// albumBudgetSpent reports whether playlist.max_albums_fetched albums were
+// fetched. The limit is noted only once per build.
+func (b *Builder) albumBudgetSpent() bool {
+	limit := b.config.Playlist.MaxAlbumsFetched
+	if limit == 0 || b.albumsFetched < limit {
+		return false
+	}
+	if !b.albumLimitHit {
+		fmt.Printf("Reached the limit of %d fetched albums, building from the tracks collected so far\n", limit)
+		b.albumLimitHit = true
+	}
+	return true
+}
+
+// takeSpares removes up to count tracks from the spares pool.
+func (b *Builder) takeSpares(count int) []models.Track {
+	count = min(count, len(b.spares))
+	taken := b.spares[:count]
+	b.spares = b.spares[count:]
+	b.sparesUsed += count
+	return taken
+}
+
+// maxTopUpRounds limits how often replacements for dropped tracks are collected.
+const maxTopUpRounds = 3
+
+// verifyAvailability checks that all tracks are still available right before
+// writing the playlist. Unavailable tracks are dropped and replaced by tracks
+// of other randomly selected artists, if any are given.
+func (b *Builder) verifyAvailability(ctx context.Context, tracks []models.Track, artists []models.ArtistID) ([]models.Track, error) {
+	fmt.Println("\nVerifying track availability...")
+
+	result := make([]models.Track, 0, len(tracks))
+	candidates := tracks
+	for round := 0; round <= maxTopUpRounds && len(candidates) > 0; round++ {
+		ids := make([]string, len(candidates))
+		for i, track := range candidates {
+			ids[i] = track.ID
+		}
+
+		available, err := b.client.GetTracks(ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to verify track availability: %w", err)
+		}
+
+		availableIDs := make(map[string]bool, len(available))
+		for _, track := range available {
+			availableIDs[track.ID] = true
+		}
+
+		dropped := 0
+		for _, track := range candidates {
+			if availableIDs[track.ID] {
+				result = append(result, track)
+				continue
+			}
+			fmt.Printf("Dropped unavailable track '%s' (%s)\n", track.Title, track.ID)
+			dropped++
+		}
+
+		if dropped == 0 || round == maxTopUpRounds {
+			break
+		}
+
+		// Replace the dropped tracks with spares first.
+		candidates = b.takeSpares(dropped)
+		dropped -= len(candidates)
+		if dropped == 0 || len(artists) == 0 {
+			continue
+		}
+
+		// Collect replacements for the remaining dropped tracks.
+		replacements, err := b.CollectTracks(ctx, b.selection.Select(b.rng, artists, dropped))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, track := range replacements {
+			if track != nil {
+				candidates = append(candidates, *track)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// collectFromFavorites collects tracks from random favorite artists. The
+// filtered artists are returned as well, to pick replacements from.
+func (b *Builder) collectFromFavorites(ctx context.Context) ([]models.Track, []models.ArtistID, error) {
+	fmt.Print("Fetching favorite artists...\n\n")
+	artists, err := b.fetchFavorites(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to fetch favorite artists: %w", err)
+		return nil, nil, fmt.Errorf("failed to fetch favorite artists: %w", err)
 	}
 
 	fmt.Printf("Found %d favorite artists\n", len(artists))
 
+	b.favoritesHash = hashArtists(artists)
+	if b.config.Playlist.OnlyIfChanged {
+		st, err := state.Load(state.DefaultPath())
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load state: %w", err)
+		}
+		if st.FavoritesHashes[b.stateKey] == b.favoritesHash {
+			return nil, nil, ErrUnchanged
+		}
+	}
+
+	if b.needsArtistDetails() {
+		if err := b.loadArtistDetails(ctx, artists); err != nil {
+			return nil, nil, fmt.Errorf("failed to load artist details: %w", err)
+		}
+	}
+
 	// Apply filters
 	filteredArtists := b.FilterArtists(artists)
 	fmt.Printf("After filtering: %d artists\n", len(filteredArtists))
 	if len(filteredArtists) == 0 {
-		return fmt.Errorf("no artists remaining after filtering")
+		return nil, nil, fmt.Errorf("no artists remaining after filtering")
+	}
+
+	if b.config.Playlist.TargetDuration > 0 {
+		finalTracks, err := b.collectDuration(ctx, filteredArtists)
+		return finalTracks, filteredArtists, err
 	}
 
-	selectedArtists := selectRandomItems(b.config.Playlist.Count, filteredArtists)
+	count := b.config.Playlist.Count
+	spareCount := (count*b.config.Playlist.SparePercent + 99) / 100
+	selectedArtists := b.selection.Select(b.rng, filteredArtists, count+spareCount)
 
 	// Collect tracks
 	fmt.Println("\nCollecting tracks from artists...")
 	tracks, err := b.CollectTracks(ctx, selectedArtists)
 	if err != nil {
-		return fmt.Errorf("failed to collect tracks: %w", err)
+		return nil, nil, fmt.Errorf("failed to collect tracks: %w", err)
 	}
 
-	fmt.Printf("\nCollected %d total tracks\n", len(tracks))
+	// Artists that didn't yield a track aren't tried again when filling
+	// the missing slots.
+	failed := make(map[string]bool)
+	markFailedArtists(failed, selectedArtists, tracks)
 
-	if len(tracks) == 0 {
-		return fmt.Errorf("no tracks collected from artists")
-	}
+	// The slots may have been reordered while collecting, so shuffle them
+	// before deciding which ones are spares.
+	b.rng.Shuffle(len(tracks), func(i, j int) {
+		tracks[i], tracks[j] = tracks[j], tracks[i]
+	})
 
 	finalTracks := []models.Track{}
-	for _, track := range tracks {
-		if track == nil {
+	missing := 0
+	for i, track := range tracks {
+		switch {
+		case track == nil && i < count:
+			missing++
+		case track == nil:
 			continue
+		case i < count:
+			finalTracks = append(finalTracks, *track)
+		default:
+			b.spares = append(b.spares, *track)
 		}
-		finalTracks = append(finalTracks, *track)
 	}
 
-	fmt.Printf("Final track count: %d\n", len(finalTracks))
+	spares := b.takeSpares(missing)
+	finalTracks = append(finalTracks, spares...)
+	missing -= len(spares)
 
-	if dryRun {
-		fmt.Println("\n=== DRY RUN MODE ===")
-		fmt.Printf("Would create/update playlist '%s' with %d tracks\n", playlistName, len(finalTracks))
-		fmt.Println("\nTracks:")
-		for i, track := range finalTracks {
-			if i >= 10 {
-				break
+	if missing > 0 {
+		more, err := b.fillMissing(ctx, filteredArtists, failed, missing)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to collect tracks: %w", err)
+		}
+		finalTracks = append(finalTracks, more...)
+	}
+
+	// Artists that failed but filled a slot in a later round are fine.
+	for id := range failed {
+		if b.artistTracks[id] == 0 {
+			b.failedArtists = append(b.failedArtists, id)
+		}
+	}
+	slices.Sort(b.failedArtists)
+
+	fmt.Printf("\nCollected %d total tracks\n", len(finalTracks)+len(b.spares))
+
+	if len(finalTracks) == 0 {
+		return nil, nil, fmt.Errorf("no tracks collected from artists")
+	}
+
+	return finalTracks, filteredArtists, nil
+}
+
+// fillMissing collects tracks for slots that stayed empty, from artists
+// that didn't fail yet, until missing tracks are collected or there is no
+// artist left to try.
+func (b *Builder) fillMissing(ctx context.Context, artists []models.ArtistID, failed map[string]bool, missing int) ([]models.Track, error) {
+	var result []models.Track
+	for missing > 0 && !b.outOfTime() {
+		candidates := slices.DeleteFunc(slices.Clone(artists), func(artist models.ArtistID) bool {
+			return failed[artist.ID] || b.artistFull(artist.ID)
+		})
+		if len(candidates) == 0 {
+			fmt.Printf("Warning: no artists left to collect the missing %d tracks from\n", missing)
+			break
+		}
+
+		fmt.Printf("\nCollecting %d missing tracks from other artists...\n", missing)
+		selected := b.selection.Select(b.rng, candidates, missing)
+		tracks, err := b.CollectTracks(ctx, selected)
+		if err != nil {
+			return nil, err
+		}
+		markFailedArtists(failed, selected, tracks)
+
+		for _, track := range tracks {
+			if track != nil {
+				result = append(result, *track)
+				missing--
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// artistFull reports whether an artist already got tracks_per_artist tracks
+// with the per_artist selection.
+func (b *Builder) artistFull(artistID string) bool {
+	return b.config.Playlist.ArtistSelection == "per_artist" &&
+		b.artistTracks[artistID] >= b.tracksPerArtist(artistID)
+}
+
+// artistOverride returns the override configured for an artist. An override
+// keyed by ID takes precedence over one keyed by name. Names are only known
+// if the artist details were loaded.
+func (b *Builder) artistOverride(artistID string) (config.ArtistOverride, bool) {
+	overrides := b.config.Playlist.ArtistOverrides
+	if override, ok := overrides[artistID]; ok {
+		return override, true
+	}
+	if name := b.artistNames[artistID]; name != "" {
+		// The config keys are lowercased like the cached names.
+		override, ok := overrides[name]
+		return override, ok
+	}
+	return config.ArtistOverride{}, false
+}
+
+// trackStrategy returns the track strategy used for an artist.
+func (b *Builder) trackStrategy(artistID string) string {
+	if override, ok := b.artistOverride(artistID); ok && override.TrackStrategy != "" {
+		return override.TrackStrategy
+	}
+	return b.config.Playlist.TrackStrategy
+}
+
+// tracksPerArtist returns tracks_per_artist for an artist.
+func (b *Builder) tracksPerArtist(artistID string) int {
+	if override, ok := b.artistOverride(artistID); ok && override.TracksPerArtist > 0 {
+		return override.TracksPerArtist
+	}
+	return b.config.Playlist.TracksPerArtist
+}
+
+// markFailedArtists marks the artists of empty slots as failed, unless they
+// filled another slot. Every round without a track fails all its artists, so
+// retrying always ends.
+func markFailedArtists(failed map[string]bool, artists []models.ArtistID, tracks []*models.Track) {
+	succeeded := make(map[string]bool)
+	for i, track := range tracks {
+		if track != nil {
+			succeeded[artists[i].ID] = true
+		}
+	}
+	for i, track := range tracks {
+		if track == nil && !succeeded[artists[i].ID] {
+			failed[artists[i].ID] = true
+		}
+	}
+}
+
+// collectFromMix samples random tracks out of the configured Tidal mix.
+func (b *Builder) collectFromMix(ctx context.Context) ([]models.Track, error) {
+	fmt.Printf("Fetching tracks of mix %s...\n", b.config.Playlist.MixID)
+	tracks, err := b.client.GetMix(ctx, b.config.Playlist.MixID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch mix: %w", err)
+	}
+
+	tracks = b.FilterTracks(tracks)
+	fmt.Printf("Found %d tracks in the mix\n", len(tracks))
+	if len(tracks) == 0 {
+		return nil, fmt.Errorf("no tracks remaining in the mix after filtering")
+	}
+
+	b.rng.Shuffle(len(tracks), func(i, j int) {
+		tracks[i], tracks[j] = tracks[j], tracks[i]
+	})
+
+	if target := b.config.Playlist.TargetDuration; target > 0 {
+		var total time.Duration
+		for i, track := range tracks {
+			total += time.Duration(track.Duration) * time.Second
+			if total >= target {
+				return tracks[:i+1], nil
 			}
-			artistNames := ""
-			if len(track.Artists) > 0 {
-				artistNames = track.Artists[0].Attributes.Name
+		}
+		return tracks, nil
+	}
+
+	if len(tracks) > b.config.Playlist.Count {
+		tracks = tracks[:b.config.Playlist.Count]
+	}
+
+	return tracks, nil
+}
+
+// averageTrackDuration is used to estimate how many tracks are needed to
+// reach the target duration.
+const averageTrackDuration = 4 * time.Minute
+
+// collectDuration collects tracks of random artists until their total
+// duration reaches the configured target duration.
+func (b *Builder) collectDuration(ctx context.Context, artists []models.ArtistID) ([]models.Track, error) {
+	target := b.config.Playlist.TargetDuration
+
+	var result []models.Track
+	var total time.Duration
+	for round := 0; round <= maxTopUpRounds && total < target; round++ {
+		missing := int((target-total)/averageTrackDuration) + 1
+
+		fmt.Println("\nCollecting tracks from artists...")
+		tracks, err := b.CollectTracks(ctx, b.selection.Select(b.rng, artists, missing))
+		if err != nil {
+			return nil, fmt.Errorf("failed to collect tracks: %w", err)
+		}
+
+		for _, track := range tracks {
+			if track == nil || total >= target {
+				continue
 			}
-			fmt.Printf("  %d. %s - %s\n", i+1, artistNames, track.Title)
+			result = append(result, *track)
+			total += time.Duration(track.Duration) * time.Second
 		}
-		fmt.Println("  ...")
-		return nil
+	}
+
+	fmt.Printf("\nCollected %d tracks with a total duration of %s\n", len(result), total)
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no tracks collected from artists")
+	}
+
+	return result, nil
+}
+
+// BuildResult summarizes a build, in dry-run mode as well.
+type BuildResult struct {
+	// PlaylistID and URL of the written playlist, of the first part if it
+	// was split. Empty in dry-run mode.
+	PlaylistID string
+	URL        string
+
+	Requested     int            // requested track count, 0 with a target duration
+	Delivered     int            // tracks selected for the playlist
+	UniqueArtists int            // distinct artists of the selected tracks, if known
+	Skipped       map[string]int // filter rejections per rule
+	Elapsed       time.Duration  // how long the build took
+	DryRun        bool
+}
+
+// BuildPlaylist orchestrates the entire playlist generation process.
+func (b *Builder) BuildPlaylist(ctx context.Context, playlistName string, dryRun bool) (*BuildResult, error) {
+	started := time.Now()
+	result := &BuildResult{DryRun: dryRun}
+	if b.config.Playlist.TargetDuration == 0 {
+		result.Requested = b.config.Playlist.Count
+	}
+
+	if err := b.buildPlaylist(ctx, playlistName, dryRun, result); err != nil {
+		return nil, err
+	}
+
+	result.Skipped = maps.Clone(b.skips)
+	result.Elapsed = time.Since(started)
+	return result, nil
+}
+
+// buildPlaylist does the work of BuildPlaylist, filling result on the way.
+func (b *Builder) buildPlaylist(ctx context.Context, playlistName string, dryRun bool, result *BuildResult) error {
+	playlistName = b.config.Playlist.NamePrefix + playlistName + b.config.Playlist.NameSuffix
+
+	// With a history, every run creates a new dated playlist instead of
+	// replacing the previous one.
+	baseName := playlistName
+	b.stateKey = baseName
+	if b.config.Playlist.KeepHistory > 0 {
+		playlistName = fmt.Sprintf("%s %s", baseName, time.Now().Format(historyDateLayout))
+	}
+
+	fmt.Printf("Random seed: %d\n", b.seed)
+
+	if b.config.Playlist.Mode == "append" {
+		if err := b.loadAppendTarget(ctx, playlistName); err != nil {
+			return err
+		}
+	}
+
+	if len(b.config.Filters.ExcludeInPlaylists) > 0 && b.fromRecipe == nil {
+		fmt.Println("Fetching tracks of excluded playlists...")
+		if err := b.loadExcludedTracks(ctx); err != nil {
+			return fmt.Errorf("failed to load excluded playlists: %w", err)
+		}
+		fmt.Printf("Excluding %d tracks\n", len(b.excludedTracks))
+	}
+
+	var finalTracks []models.Track
+	var filteredArtists []models.ArtistID
+	var err error
+	if b.fromRecipe != nil {
+		fmt.Printf("Using the %d tracks of the recipe\n", len(b.fromRecipe.Tracks))
+		for _, track := range b.fromRecipe.Tracks {
+			finalTracks = append(finalTracks, models.Track{ID: track.ID, Title: track.Title})
+		}
+	} else if b.config.Playlist.Source == "mix" {
+		finalTracks, err = b.collectFromMix(ctx)
+	} else {
+		finalTracks, filteredArtists, err = b.collectFromFavorites(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	if b.config.Playlist.VerifyAvailability {
+		finalTracks, err = b.verifyAvailability(ctx, finalTracks, filteredArtists)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Collected tracks are already deduplicated, this catches duplicates
+	// of recipes and mixes.
+	if !b.config.Playlist.AllowDuplicates {
+		finalTracks = uniqueTracks(finalTracks)
+	}
+	if len(b.existingTracks) > 0 {
+		finalTracks = slices.DeleteFunc(finalTracks, func(track models.Track) bool {
+			return b.existingTracks[track.ID]
+		})
+	}
+
+	fmt.Printf("Final track count: %d\n", len(finalTracks))
+	if b.config.Playlist.SparePercent > 0 {
+		fmt.Printf("Used %d spare tracks\n", b.sparesUsed)
+	}
+	if b.config.Filters.ExplainSkips {
+		b.printSkipSummary()
+	}
+	if b.timeBounded {
+		fmt.Println("Note: the build was time-bounded by the deadline, the playlist may be shorter than requested")
+	}
+
+	if len(finalTracks) < b.config.Playlist.MinTracks {
+		return fmt.Errorf("only %d tracks collected, at least %d required", len(finalTracks), b.config.Playlist.MinTracks)
+	}
+	result.Delivered = len(finalTracks)
+	artistIDs := make(map[string]bool)
+	for _, track := range finalTracks {
+		if track.ArtistID != "" {
+			artistIDs[track.ArtistID] = true
+		}
+	}
+	result.UniqueArtists = len(artistIDs)
+
+	if b.recipePath != "" {
+		if err := b.saveRecipe(playlistName, finalTracks); err != nil {
+			return fmt.Errorf("failed to save recipe: %w", err)
+		}
+		fmt.Printf("Saved recipe to %s\n", b.recipePath)
+	}
+
+	if b.exportPath != "" {
+		if err := b.exportTracks(ctx, playlistName, finalTracks); err != nil {
+			return fmt.Errorf("failed to export playlist: %w", err)
+		}
+		fmt.Printf("Exported %d tracks to %s\n", len(finalTracks), b.exportPath)
 	}
 
 	// Extract track IDs
@@ -203,13 +1122,360 @@ func (b *Builder) BuildPlaylist(ctx context.Context, playlistName string, dryRun
 		trackIDs[i] = track.ID
 	}
 
-	// Create or update playlist
-	fmt.Printf("\nCreating/updating playlist '%s'...\n", playlistName)
-	playlist, err := b.client.CreateOrUpdatePlaylist(ctx, playlistName, "Generated by tidal-playlist", trackIDs)
+	// Everything that doesn't write to Tidal has to happen before this
+	// point, so that it runs in dry-run mode as well.
+	if dryRun {
+		b.printPreview(playlistName, finalTracks)
+		if b.config.Playlist.KeepHistory > 0 {
+			var planned []string
+			for _, part := range splitPlaylist(playlistName, trackIDs, b.config.Playlist.MaxSize) {
+				planned = append(planned, part.name)
+			}
+			return b.pruneHistory(ctx, baseName, planned)
+		}
+		return nil
+	}
+
+	// Don't start writing if the run was cancelled while collecting.
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("interrupted before writing the playlist: %w", err)
+	}
+
+	if b.queuePath != "" {
+		return b.queueWrites(playlistName, trackIDs)
+	}
+
+	var lastPlaylistID string
+	// In append mode, the tracks are added to the existing playlist.
+	var parts []playlistPart
+	if b.appendTo != nil {
+		if err := b.client.AddTracksToPlaylist(ctx, b.appendTo.GetID(), trackIDs); err != nil {
+			return fmt.Errorf("failed to append to playlist: %w", err)
+		}
+		fmt.Printf("\n✓ Success! Appended %d tracks to playlist '%s'\n", len(trackIDs), b.appendTo.GetTitle())
+		fmt.Printf("  %s\n", b.appendTo.URL())
+		lastPlaylistID = b.appendTo.GetID()
+		result.PlaylistID = b.appendTo.GetID()
+		result.URL = b.appendTo.URL()
+	} else {
+		parts = splitPlaylist(playlistName, trackIDs, b.config.Playlist.MaxSize)
+	}
+	for _, part := range parts {
+		// Create or update playlist
+		fmt.Printf("\nCreating/updating playlist '%s'...\n", part.name)
+		description, err := b.playlistDescription(ctx, part.name)
+		if err != nil {
+			return err
+		}
+
+		playlist, err := b.client.CreateOrUpdatePlaylist(ctx, part.name, description, part.trackIDs)
+		if err != nil {
+			return fmt.Errorf("failed to create/update playlist: %w", err)
+		}
+
+		fmt.Printf("\n✓ Success! Playlist '%s' created/updated with %d tracks\n", playlist.GetTitle(), playlist.NumberOfTracks)
+		fmt.Printf("  %s\n", playlist.URL())
+		lastPlaylistID = playlist.GetID()
+		if result.PlaylistID == "" {
+			result.PlaylistID = playlist.GetID()
+			result.URL = playlist.URL()
+		}
+	}
+
+	if b.favoritesHash != "" {
+		if err := b.saveFavoritesHash(); err != nil {
+			fmt.Printf("Warning: failed to save state: %v\n", err)
+		}
+		if err := b.saveFailures(lastPlaylistID); err != nil {
+			fmt.Printf("Warning: failed to save state: %v\n", err)
+		}
+		if len(b.failedArtists) > 0 {
+			fmt.Printf("Note: %d artists contributed no tracks, try them again with --retry-failed\n", len(b.failedArtists))
+		}
+	}
+
+	if b.config.Playlist.AlsoLike {
+		if err := b.client.AddTracksToFavorites(ctx, trackIDs); err != nil {
+			return fmt.Errorf("failed to add tracks to favorites: %w", err)
+		}
+		fmt.Printf("✓ Added %d tracks to your favorites\n", len(trackIDs))
+	}
+
+	if b.config.Playlist.KeepHistory > 0 {
+		return b.pruneHistory(ctx, baseName, nil)
+	}
+	return nil
+}
+
+// saveRecipe writes the selected tracks together with the seed and filters
+// to the recipe path.
+func (b *Builder) saveRecipe(playlistName string, tracks []models.Track) error {
+	r := recipe.Recipe{
+		Name:    playlistName,
+		Created: time.Now(),
+		Seed:    b.seed,
+		Filters: b.config.Filters,
+		Tracks:  make([]recipe.Track, len(tracks)),
+	}
+	for i, track := range tracks {
+		r.Tracks[i] = recipe.Track{ID: track.ID, Title: track.Title}
+	}
+	return recipe.Write(b.recipePath, r)
+}
+
+// exportTracks writes the tracks to the export path. The tracks are fetched
+// again to get the names of all of their artists.
+func (b *Builder) exportTracks(ctx context.Context, playlistName string, tracks []models.Track) error {
+	ids := make([]string, len(tracks))
+	for i, track := range tracks {
+		ids[i] = track.ID
+	}
+
+	fetched, err := b.client.GetTracks(ctx, ids)
+	if err != nil {
+		return err
+	}
+	artists := make(map[string][]models.Artist, len(fetched))
+	for _, track := range fetched {
+		artists[track.ID] = track.Artists
+	}
+
+	exported := make([]models.Track, len(tracks))
+	for i, track := range tracks {
+		if len(artists[track.ID]) > 0 {
+			track.Artists = artists[track.ID]
+		}
+		exported[i] = track
+	}
+
+	return export.WriteM3U(b.exportPath, playlistName, exported, b.config.Export.BOM)
+}
+
+// hashArtists returns a hash of the set of artists, independent of their order.
+func hashArtists(artists []models.ArtistID) string {
+	ids := make([]string, len(artists))
+	for i, artist := range artists {
+		ids[i] = artist.ID
+	}
+	slices.Sort(ids)
+
+	sum := sha256.Sum256([]byte(strings.Join(ids, ",")))
+	return hex.EncodeToString(sum[:])
+}
+
+// saveFavoritesHash remembers the favorite artists the playlist was built from.
+func (b *Builder) saveFavoritesHash() error {
+	path := state.DefaultPath()
+	st, err := state.Load(path)
+	if err != nil {
+		return err
+	}
+
+	if st.FavoritesHashes == nil {
+		st.FavoritesHashes = make(map[string]string)
+	}
+	st.FavoritesHashes[b.stateKey] = b.favoritesHash
+	return st.Save(path)
+}
+
+// loadAppendTarget looks up the playlist to append to in append mode, and
+// its tracks so that they aren't added again. Without an existing playlist,
+// it is created as usual.
+func (b *Builder) loadAppendTarget(ctx context.Context, playlistName string) error {
+	playlist, err := b.client.FindPlaylistByName(ctx, playlistName)
+	if err != nil {
+		return fmt.Errorf("failed to find playlist: %w", err)
+	}
+	if playlist == nil {
+		fmt.Printf("No playlist '%s' to append to yet, it is created\n", playlistName)
+		return nil
+	}
+
+	items, err := b.client.GetPlaylistItems(ctx, playlist.GetID())
+	if err != nil {
+		return fmt.Errorf("failed to get tracks of playlist '%s': %w", playlistName, err)
+	}
+
+	b.appendTo = playlist
+	b.existingTracks = make(map[string]bool, len(items))
+	for _, item := range items {
+		b.existingTracks[item.ID] = true
+	}
+	fmt.Printf("Appending to '%s', which has %d tracks\n", playlistName, len(items))
+	return nil
+}
+
+// saveFailures remembers the artists that contributed no track, to try them
+// again with RetryFailed. The tracks are appended to the given playlist.
+func (b *Builder) saveFailures(playlistID string) error {
+	path := state.DefaultPath()
+	st, err := state.Load(path)
 	if err != nil {
-		return fmt.Errorf("failed to create/update playlist: %w", err)
+		return err
+	}
+
+	if len(b.failedArtists) == 0 {
+		delete(st.Failures, b.stateKey)
+	} else {
+		if st.Failures == nil {
+			st.Failures = make(map[string]state.Failure)
+		}
+		st.Failures[b.stateKey] = state.Failure{PlaylistID: playlistID, ArtistIDs: b.failedArtists}
+	}
+	return st.Save(path)
+}
+
+// printPreview prints what a run would write in dry-run mode.
+func (b *Builder) printPreview(playlistName string, tracks []models.Track) {
+	fmt.Println("\n=== DRY RUN MODE ===")
+	if maxSize := b.config.Playlist.MaxSize; maxSize > 0 && len(tracks) > maxSize {
+		fmt.Printf("Would split %d tracks into %d playlists named '%s (n)'\n", len(tracks), (len(tracks)+maxSize-1)/maxSize, playlistName)
+	} else {
+		fmt.Printf("Would create/update playlist '%s' with %d tracks\n", playlistName, len(tracks))
+	}
+	fmt.Println("\nTracks:")
+	for i, track := range tracks {
+		if i >= 10 {
+			break
+		}
+		artistNames := ""
+		if len(track.Artists) > 0 {
+			artistNames = track.Artists[0].Attributes.Name
+		}
+		fmt.Printf("  %d. %s - %s\n", i+1, artistNames, track.Title)
+	}
+	fmt.Println("  ...")
+
+	if b.config.Playlist.AlsoLike {
+		fmt.Printf("Would add %d tracks to your favorites\n", len(tracks))
+	}
+}
+
+// playlistPart is one of the playlists a too large playlist is split into.
+type playlistPart struct {
+	name     string
+	trackIDs []string
+}
+
+// splitPlaylist splits the tracks into numbered playlists of at most maxSize
+// tracks. With maxSize 0 or few enough tracks, a single playlist is returned.
+func splitPlaylist(name string, trackIDs []string, maxSize int) []playlistPart {
+	if maxSize <= 0 || len(trackIDs) <= maxSize {
+		return []playlistPart{{name: name, trackIDs: trackIDs}}
+	}
+
+	var parts []playlistPart
+	for i := 0; i < len(trackIDs); i += maxSize {
+		end := min(i+maxSize, len(trackIDs))
+		parts = append(parts, playlistPart{
+			name:     fmt.Sprintf("%s (%d)", name, len(parts)+1),
+			trackIDs: trackIDs[i:end],
+		})
+	}
+	return parts
+}
+
+// playlistDescription returns the description for the playlist. Without a
+// configured description, the one of the existing playlist is kept.
+func (b *Builder) playlistDescription(ctx context.Context, playlistName string) (string, error) {
+	description := b.config.Playlist.Description
+	if description == "" {
+		existing, err := b.client.FindPlaylistByName(ctx, playlistName)
+		if err != nil {
+			return "", fmt.Errorf("failed to look up the current description: %w", err)
+		}
+		if existing != nil {
+			current, err := b.client.GetPlaylist(ctx, existing.GetID())
+			if err != nil {
+				return "", fmt.Errorf("failed to look up the current description: %w", err)
+			}
+			description = current.Description
+		}
+	}
+
+	if description == "" {
+		return GeneratorMarker, nil
+	}
+
+	// The history cleanup only touches playlists carrying the marker.
+	if b.config.Playlist.KeepHistory > 0 && !strings.Contains(description, GeneratorMarker) {
+		description += " (" + GeneratorMarker + ")"
+	}
+	return description, nil
+}
+
+// historyDate returns the date in the name of a history playlist of the
+// given base name. Names of other playlists, including other series whose
+// name merely starts with the base name, are reported as not matching.
+func historyDate(title, baseName string) (time.Time, bool) {
+	rest, ok := strings.CutPrefix(title, baseName)
+	if !ok {
+		return time.Time{}, false
+	}
+	match := historySuffix.FindStringSubmatch(rest)
+	if match == nil {
+		return time.Time{}, false
+	}
+	date, err := time.Parse(historyDateLayout, match[1])
+	return date, err == nil
+}
+
+// pruneHistory deletes generated playlists of the given base name, keeping
+// only the configured number of most recent dates. All parts of a split
+// playlist belong to the same date. In dry-run mode, planned are the names
+// of the playlists the build would create, nil otherwise.
+func (b *Builder) pruneHistory(ctx context.Context, baseName string, planned []string) error {
+	playlists, err := b.client.GetUserPlaylists(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch playlists for history cleanup: %w", err)
+	}
+
+	byDate := make(map[time.Time][]models.Playlist)
+	for _, playlist := range playlists {
+		if !strings.Contains(playlist.Description, GeneratorMarker) {
+			continue
+		}
+		if date, ok := historyDate(playlist.GetTitle(), baseName); ok {
+			byDate[date] = append(byDate[date], playlist)
+		}
+	}
+
+	// The playlists that would have been created count as well.
+	for _, name := range planned {
+		if date, ok := historyDate(name, baseName); ok {
+			if _, exists := byDate[date]; !exists {
+				byDate[date] = nil
+			}
+		}
+	}
+
+	// Newest first.
+	dates := slices.SortedFunc(maps.Keys(byDate), func(a, b time.Time) int {
+		return b.Compare(a)
+	})
+	keep := b.config.Playlist.KeepHistory
+	if len(dates) <= keep {
+		return nil
+	}
+
+	dryRun := planned != nil
+	for _, date := range dates[keep:] {
+		old := byDate[date]
+		slices.SortFunc(old, func(a, b models.Playlist) int {
+			return strings.Compare(a.GetTitle(), b.GetTitle())
+		})
+		for _, playlist := range old {
+			if dryRun {
+				fmt.Printf("Would delete old playlist '%s'\n", playlist.GetTitle())
+				continue
+			}
+
+			fmt.Printf("Deleting old playlist '%s'...\n", playlist.GetTitle())
+			if err := b.client.DeletePlaylist(ctx, playlist.GetID()); err != nil {
+				return fmt.Errorf("failed to delete old playlist '%s': %w", playlist.GetTitle(), err)
+			}
+		}
 	}
 
-	fmt.Printf("\n✓ Success! Playlist '%s' created/updated with %d tracks\n", playlist.GetTitle(), len(trackIDs))
 	return nil
 }
@@ -0,0 +1,281 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/aligator/tidal-playlist/internal/config"
+)
+
+func TestBuildKeepsExistingDescription(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string // added to the playlist section
+		existing string // description of the existing playlist, none if empty
+		want     string
+	}{
+		{
+			name:     "no description keeps the existing one",
+			existing: "Songs for the morning",
+			want:     "Songs for the morning",
+		},
+		{
+			name:     "configured description replaces the existing one",
+			config:   "  description: Fresh picks\n",
+			existing: "Songs for the morning",
+			want:     "Fresh picks",
+		},
+		{
+			name: "no description and no existing playlist",
+			want: GeneratorMarker,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeTidal(3, 2, 3)
+			var old *fakePlaylist
+			if tt.existing != "" {
+				old = fake.addPlaylist("Daily Mix", tt.existing)
+			}
+			b := newTestBuilder(t, fake, "playlist:\n  count: 3\n"+tt.config)
+
+			if _, err := b.BuildPlaylist(context.Background(), "Daily Mix", false); err != nil {
+				t.Fatalf("BuildPlaylist failed: %v", err)
+			}
+
+			playlist := fake.playlist("Daily Mix")
+			if playlist == nil {
+				t.Fatal("playlist was not written")
+			}
+			if old != nil && playlist.ID == old.ID {
+				t.Fatal("the existing playlist was not replaced")
+			}
+			if playlist.Description != tt.want {
+				t.Errorf("description = %q, want %q", playlist.Description, tt.want)
+			}
+			if len(playlist.Tracks) != 3 {
+				t.Errorf("got %d tracks, want 3", len(playlist.Tracks))
+			}
+		})
+	}
+}
+
+// buildTracks runs a full build with the given seed against a new fake API
+// and returns the tracks of the written playlist.
+func buildTracks(t *testing.T, seed int) []string {
+	t.Helper()
+
+	fake := newFakeTidal(8, 4, 6)
+	config := fmt.Sprintf("playlist:\n  count: 10\n  seed: %d\n  spare_percent: 20\n  prefer_recent: true\n", seed)
+	b := newTestBuilder(t, fake, config)
+	if _, err := b.BuildPlaylist(context.Background(), "Daily Mix", false); err != nil {
+		t.Fatalf("BuildPlaylist failed: %v", err)
+	}
+
+	playlist := fake.playlist("Daily Mix")
+	if playlist == nil {
+		t.Fatal("playlist was not written")
+	}
+	return playlist.Tracks
+}
+
+func TestBuildIsDeterministicWithSeed(t *testing.T) {
+	first := buildTracks(t, 42)
+	if len(first) != 10 {
+		t.Fatalf("got %d tracks, want 10", len(first))
+	}
+
+	second := buildTracks(t, 42)
+	if !slices.Equal(first, second) {
+		t.Errorf("builds with the same seed differ:\n%v\n%v", first, second)
+	}
+
+	// Make sure the seed is what decides the picks.
+	if other := buildTracks(t, 43); slices.Equal(first, other) {
+		t.Errorf("builds with different seeds are equal: %v", first)
+	}
+}
+
+func TestArtistOverrideResolution(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(configPath, []byte(`playlist:
+  track_strategy: random
+  tracks_per_artist: 5
+  artist_overrides:
+    "100":
+      track_strategy: artist_top
+      tracks_per_artist: 10
+    "Miles Davis":
+      tracks_per_artist: 2
+    "Radiohead":
+      track_strategy: artist_top
+    "101":
+      tracks_per_artist: 3
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	b := NewBuilder(nil, cfg)
+	if !b.needsArtistDetails() {
+		t.Error("overrides keyed by name don't load the artist names")
+	}
+	// Names are cached lowercased by loadArtistDetails.
+	b.artistNames["100"] = "radiohead"
+	b.artistNames["101"] = "miles davis"
+	b.artistNames["102"] = "miles davis"
+	b.artistNames["103"] = "radiohead"
+	b.artistNames["104"] = "portishead"
+
+	tests := []struct {
+		artistID     string
+		wantStrategy string
+		wantTracks   int
+	}{
+		// The ID override wins over the name override as a whole.
+		{"100", "artist_top", 10},
+		{"101", "random", 3},
+		// Name overrides match case-insensitively, left out settings
+		// keep the global value.
+		{"102", "random", 2},
+		{"103", "artist_top", 5},
+		// Without any override the global settings apply.
+		{"104", "random", 5},
+		// An artist without a known name can only match by ID.
+		{"105", "random", 5},
+	}
+	for _, tt := range tests {
+		if got := b.trackStrategy(tt.artistID); got != tt.wantStrategy {
+			t.Errorf("trackStrategy(%s) = %q, want %q", tt.artistID, got, tt.wantStrategy)
+		}
+		if got := b.tracksPerArtist(tt.artistID); got != tt.wantTracks {
+			t.Errorf("tracksPerArtist(%s) = %d, want %d", tt.artistID, got, tt.wantTracks)
+		}
+	}
+}
+
+func TestNeedsArtistDetailsForIDOverrides(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Playlist.ArtistOverrides = map[string]config.ArtistOverride{
+		"3510943": {TrackStrategy: "artist_top"},
+	}
+	if NewBuilder(nil, cfg).needsArtistDetails() {
+		t.Error("overrides keyed by ID only load the artist names")
+	}
+}
+
+// historyFixture adds the playlists of a daily history of "Mix" to fake,
+// together with playlists that must never be pruned.
+func historyFixture(fake *fakeTidal) {
+	for _, name := range []string{
+		"Mix 2026-10-01",
+		"Mix 2026-10-02 (1)",
+		"Mix 2026-10-02 (2)",
+		"Mix 2026-10-03 (1)",
+		"Mix 2026-10-03 (2)",
+		// Other generated series starting with the same words.
+		"Mix Daily 2026-09-01",
+		"Mix 2026-09-02 copy",
+		"Mixtape 2026-09-03",
+	} {
+		fake.addPlaylist(name, GeneratorMarker)
+	}
+	// Created by the user.
+	fake.addPlaylist("Mix 2026-09-04", "My favorites")
+}
+
+func playlistNames(fake *fakeTidal) []string {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	var names []string
+	for _, playlist := range fake.playlists {
+		names = append(names, playlist.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestPruneHistory(t *testing.T) {
+	fake := newFakeTidal(1, 1, 1)
+	historyFixture(fake)
+	b := newTestBuilder(t, fake, "playlist:\n  keep_history: 2\n")
+
+	if err := b.pruneHistory(context.Background(), "Mix", nil); err != nil {
+		t.Fatalf("pruneHistory failed: %v", err)
+	}
+
+	// The two newest days are kept with all their parts.
+	want := []string{
+		"Mix 2026-09-02 copy",
+		"Mix 2026-09-04",
+		"Mix 2026-10-02 (1)",
+		"Mix 2026-10-02 (2)",
+		"Mix 2026-10-03 (1)",
+		"Mix 2026-10-03 (2)",
+		"Mix Daily 2026-09-01",
+		"Mixtape 2026-09-03",
+	}
+	if got := playlistNames(fake); !slices.Equal(got, want) {
+		t.Errorf("playlists after pruning:\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestPruneHistoryDryRun(t *testing.T) {
+	fake := newFakeTidal(1, 1, 1)
+	historyFixture(fake)
+	b := newTestBuilder(t, fake, "playlist:\n  keep_history: 2\n")
+	before := playlistNames(fake)
+
+	// The planned parts of a new day take one of the two kept days.
+	planned := []string{"Mix 2026-10-04 (1)", "Mix 2026-10-04 (2)", "Mix 2026-10-04 (3)"}
+	output := captureStdout(t, func() {
+		if err := b.pruneHistory(context.Background(), "Mix", planned); err != nil {
+			t.Errorf("pruneHistory failed: %v", err)
+		}
+	})
+
+	want := "Would delete old playlist 'Mix 2026-10-02 (1)'\n" +
+		"Would delete old playlist 'Mix 2026-10-02 (2)'\n" +
+		"Would delete old playlist 'Mix 2026-10-01'\n"
+	if output != want {
+		t.Errorf("output:\n%s\nwant\n%s", output, want)
+	}
+	if after := playlistNames(fake); !slices.Equal(after, before) {
+		t.Errorf("dry run changed the playlists to %v", after)
+	}
+}
+
+func TestHistoryDate(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string // empty if not part of the history
+	}{
+		{"Mix 2026-10-01", "2026-10-01"},
+		{"Mix 2026-10-01 (12)", "2026-10-01"},
+		{"Mix Daily 2026-10-01", ""},
+		{"Mix 2026-10-01 copy", ""},
+		{"Mix 2026-13-01", ""},
+		{"Mix 2026-10-01 ()", ""},
+		{"Mixtape 2026-10-01", ""},
+		{"Mix", ""},
+	}
+	for _, tt := range tests {
+		date, ok := historyDate(tt.title, "Mix")
+		got := ""
+		if ok {
+			got = date.Format(historyDateLayout)
+		}
+		if got != tt.want {
+			t.Errorf("historyDate(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
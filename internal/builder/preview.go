@@ -0,0 +1,170 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aligator/tidal-playlist/internal/models"
+)
+
+// Rejection is why a filter rule rejected an item.
+type Rejection struct {
+	Rule   string
+	Reason string
+}
+
+// TrackPreview is a candidate track and whether the filters keep it.
+type TrackPreview struct {
+	Track    models.Track
+	Rejected *Rejection // nil if the track is kept
+}
+
+// AlbumPreview is an album and whether the filters keep it. The tracks are
+// only fetched for kept albums.
+type AlbumPreview struct {
+	Album    models.Album
+	Rejected *Rejection // nil if the album is kept
+	Tracks   []TrackPreview
+}
+
+// ArtistPreview shows which albums and tracks of an artist survive the
+// filters.
+type ArtistPreview struct {
+	Artist        models.Artist
+	Rejected      *Rejection // nil if the artist is kept
+	TrackStrategy string
+	Relaxed       bool           // the relaxable album filters were skipped
+	Albums        []AlbumPreview // with the random track strategy
+	TopTracks     []TrackPreview // with the artist_top track strategy
+}
+
+// Candidates returns the number of tracks the artist can contribute.
+func (p *ArtistPreview) Candidates() int {
+	if p.Rejected != nil {
+		return 0
+	}
+
+	candidates := 0
+	for _, track := range p.TopTracks {
+		if track.Rejected == nil {
+			candidates++
+		}
+	}
+	for _, album := range p.Albums {
+		for _, track := range album.Tracks {
+			if track.Rejected == nil {
+				candidates++
+			}
+		}
+	}
+	return candidates
+}
+
+// PreviewArtist runs one artist through the filter pipeline and fetches its
+// albums and tracks the way a build does, recording why items are rejected.
+// Nothing is counted in the skip summary.
+func (b *Builder) PreviewArtist(ctx context.Context, artistID string) (*ArtistPreview, error) {
+	artist, err := b.client.GetArtist(ctx, artistID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get artist %s: %w", artistID, err)
+	}
+
+	if len(b.config.Filters.ExcludeInPlaylists) > 0 {
+		if err := b.loadExcludedTracks(ctx); err != nil {
+			return nil, fmt.Errorf("failed to load excluded playlists: %w", err)
+		}
+	}
+
+	id := models.ArtistID{ID: artist.ID}
+	if b.needsArtistDetails() {
+		if err := b.loadArtistDetails(ctx, []models.ArtistID{id}); err != nil {
+			return nil, fmt.Errorf("failed to load artist details: %w", err)
+		}
+	}
+
+	preview := &ArtistPreview{
+		Artist:        *artist,
+		Rejected:      b.rejection(func(filter Filter) (bool, string) { return filter.KeepArtist(id) }),
+		TrackStrategy: b.trackStrategy(artist.ID),
+	}
+
+	if preview.TrackStrategy == "artist_top" {
+		tracks, err := b.client.GetArtistTopTracks(ctx, artist.ID, b.tracksPerArtist(artist.ID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get top tracks of artist %s: %w", artist.ID, err)
+		}
+		preview.TopTracks = b.previewTracks(tracks)
+		return preview, nil
+	}
+
+	albums, err := b.client.GetArtistAlbums(ctx, artist.ID, 100)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get albums of artist %s: %w", artist.ID, err)
+	}
+	preview.Albums = b.previewAlbums(albums, false)
+	if b.config.Filters.RelaxForEmptyArtists && !anyAlbumKept(preview.Albums) {
+		preview.Albums = b.previewAlbums(albums, true)
+		preview.Relaxed = true
+	}
+
+	for i, album := range preview.Albums {
+		if album.Rejected != nil {
+			continue
+		}
+		tracks, err := b.client.GetAlbumTracks(ctx, album.Album.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tracks of album %s: %w", album.Album.ID, err)
+		}
+		preview.Albums[i].Tracks = b.previewTracks(tracks)
+	}
+
+	return preview, nil
+}
+
+// previewAlbums runs albums through the album filters, like filterAlbums.
+func (b *Builder) previewAlbums(albums []models.Album, relaxed bool) []AlbumPreview {
+	result := make([]AlbumPreview, len(albums))
+	for i, album := range albums {
+		result[i] = AlbumPreview{
+			Album: album,
+			Rejected: b.rejection(func(filter Filter) (bool, string) {
+				if relaxed && relaxableFilters[filter.Name()] {
+					return true, ""
+				}
+				return filter.KeepAlbum(album)
+			}),
+		}
+	}
+	return result
+}
+
+// previewTracks runs tracks through the track filters.
+func (b *Builder) previewTracks(tracks []models.Track) []TrackPreview {
+	result := make([]TrackPreview, len(tracks))
+	for i, track := range tracks {
+		result[i] = TrackPreview{
+			Track:    track,
+			Rejected: b.rejection(func(filter Filter) (bool, string) { return filter.KeepTrack(track) }),
+		}
+	}
+	return result
+}
+
+// rejection returns the first rejection of an item by the pipeline, or nil
+// if all filters keep it.
+func (b *Builder) rejection(decide func(Filter) (bool, string)) *Rejection {
+	rule, reason, rejected := b.firstRejection(decide)
+	if !rejected {
+		return nil
+	}
+	return &Rejection{Rule: rule, Reason: reason}
+}
+
+func anyAlbumKept(albums []AlbumPreview) bool {
+	for _, album := range albums {
+		if album.Rejected == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aligator/tidal-playlist/internal/queue"
+)
+
+// DeferWrite makes the builder add the playlist to the queue file at path
+// instead of writing it, see FlushQueue.
+func (b *Builder) DeferWrite(path string) {
+	b.queuePath = path
+}
+
+// queueWrites adds the playlist, split like it would be written, to the
+// queue file.
+func (b *Builder) queueWrites(playlistName string, trackIDs []string) error {
+	q, err := queue.Load(b.queuePath)
+	if err != nil {
+		return fmt.Errorf("failed to load queue: %w", err)
+	}
+
+	parts := splitPlaylist(playlistName, trackIDs, b.config.Playlist.MaxSize)
+	for i, part := range parts {
+		w := queue.Write{
+			Name:        part.name,
+			Description: b.config.Playlist.Description,
+			TrackIDs:    part.trackIDs,
+			Queued:      time.Now(),
+		}
+		// Like a direct write, the state refers to the last part.
+		if i == len(parts)-1 && b.favoritesHash != "" {
+			w.StateKey = b.stateKey
+			w.FavoritesHash = b.favoritesHash
+			w.FailedArtists = b.failedArtists
+		}
+		q.Add(w)
+		fmt.Printf("\n✓ Queued playlist '%s' with %d tracks in %s\n", part.name, len(part.trackIDs), b.queuePath)
+	}
+
+	if err := q.Save(b.queuePath); err != nil {
+		return fmt.Errorf("failed to save queue: %w", err)
+	}
+	fmt.Println("Write it with 'tidal-playlist flush-queue " + b.queuePath + "'")
+	return nil
+}
+
+// FlushQueue writes the playlists of the queue file at path in the order
+// they were queued, and saves the state of their builds. Every written
+// playlist is removed from the queue right away, so a flush that failed
+// halfway can be run again.
+func (b *Builder) FlushQueue(ctx context.Context, path string, dryRun bool) error {
+	q, err := queue.Load(path)
+	if err != nil {
+		return fmt.Errorf("failed to load queue: %w", err)
+	}
+	if len(q.Writes) == 0 {
+		fmt.Println("The queue is empty")
+		return nil
+	}
+
+	if dryRun {
+		fmt.Printf("Would write %d playlists:\n", len(q.Writes))
+		for _, w := range q.Writes {
+			fmt.Printf("  %s (%d tracks, queued %s)\n", w.Name, len(w.TrackIDs), w.Queued.Format("2006-01-02 15:04"))
+		}
+		return nil
+	}
+
+	for len(q.Writes) > 0 {
+		w := q.Writes[0]
+
+		description := w.Description
+		if description == "" {
+			description, err = b.playlistDescription(ctx, w.Name)
+			if err != nil {
+				return err
+			}
+		}
+
+		fmt.Printf("\nCreating/updating playlist '%s'...\n", w.Name)
+		playlist, err := b.client.CreateOrUpdatePlaylist(ctx, w.Name, description, w.TrackIDs)
+		if err != nil {
+			return fmt.Errorf("failed to create/update playlist: %w", err)
+		}
+		fmt.Printf("\n✓ Success! Playlist '%s' created/updated with %d tracks\n", playlist.GetTitle(), playlist.NumberOfTracks)
+		fmt.Printf("  %s\n", playlist.URL())
+
+		if w.FavoritesHash != "" {
+			b.saveQueuedState(w, playlist.GetID())
+		}
+
+		q.Writes = q.Writes[1:]
+		if err := q.Save(path); err != nil {
+			return fmt.Errorf("failed to save queue: %w", err)
+		}
+	}
+	return nil
+}
+
+// saveQueuedState saves the state of the build a queued write came from,
+// now that its playlist exists.
+func (b *Builder) saveQueuedState(w queue.Write, playlistID string) {
+	b.stateKey = w.StateKey
+	b.favoritesHash = w.FavoritesHash
+	b.failedArtists = w.FailedArtists
+
+	if err := b.saveFavoritesHash(); err != nil {
+		fmt.Printf("Warning: failed to save state: %v\n", err)
+	}
+	if err := b.saveFailures(playlistID); err != nil {
+		fmt.Printf("Warning: failed to save state: %v\n", err)
+	}
+	if len(b.failedArtists) > 0 {
+		fmt.Printf("Note: %d artists contributed no tracks, try them again with --retry-failed\n", len(b.failedArtists))
+	}
+}
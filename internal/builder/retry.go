@@ -0,0 +1,92 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aligator/tidal-playlist/internal/models"
+	"github.com/aligator/tidal-playlist/internal/state"
+)
+
+// RetryFailed tries the artists that contributed no track to the last build
+// of the playlist again, and appends the tracks found to the playlist.
+// Artists that fail again are kept for the next retry.
+func (b *Builder) RetryFailed(ctx context.Context, playlistName string, dryRun bool) error {
+	playlistName = b.config.Playlist.NamePrefix + playlistName + b.config.Playlist.NameSuffix
+
+	statePath := state.DefaultPath()
+	st, err := state.Load(statePath)
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	failure, ok := st.Failures[playlistName]
+	if !ok || len(failure.ArtistIDs) == 0 {
+		fmt.Printf("No failed artists recorded for '%s'\n", playlistName)
+		return nil
+	}
+
+	// Don't add tracks the playlist already contains.
+	items, err := b.client.GetPlaylistItems(ctx, failure.PlaylistID)
+	if err != nil {
+		return fmt.Errorf("failed to get tracks of playlist '%s': %w", playlistName, err)
+	}
+	existing := make(map[string]bool, len(items))
+	for _, item := range items {
+		existing[item.ID] = true
+	}
+
+	artists := make([]models.ArtistID, len(failure.ArtistIDs))
+	for i, id := range failure.ArtistIDs {
+		artists[i] = models.ArtistID{ID: id}
+	}
+
+	if b.needsArtistDetails() {
+		if err := b.loadArtistDetails(ctx, artists); err != nil {
+			return fmt.Errorf("failed to load artist details: %w", err)
+		}
+	}
+
+	fmt.Printf("Retrying %d artists that contributed no tracks...\n", len(artists))
+	collected, err := b.CollectTracks(ctx, artists)
+	if err != nil {
+		return fmt.Errorf("failed to collect tracks: %w", err)
+	}
+
+	var trackIDs []string
+	var stillFailed []string
+	for i, track := range collected {
+		switch {
+		case track == nil:
+			stillFailed = append(stillFailed, artists[i].ID)
+		case !existing[track.ID]:
+			trackIDs = append(trackIDs, track.ID)
+		}
+	}
+
+	if dryRun {
+		fmt.Printf("\nWould append %d tracks to '%s', %d artists still failed\n", len(trackIDs), playlistName, len(stillFailed))
+		return nil
+	}
+
+	if len(trackIDs) > 0 {
+		if err := b.client.AddTracksToPlaylist(ctx, failure.PlaylistID, trackIDs); err != nil {
+			return err
+		}
+		fmt.Printf("\n✓ Appended %d tracks to '%s'\n", len(trackIDs), playlistName)
+	}
+	if len(stillFailed) > 0 {
+		fmt.Printf("%d artists still contributed no tracks\n", len(stillFailed))
+	}
+
+	if len(stillFailed) == 0 {
+		delete(st.Failures, playlistName)
+	} else {
+		failure.ArtistIDs = stillFailed
+		st.Failures[playlistName] = failure
+	}
+	if err := st.Save(statePath); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return nil
+}
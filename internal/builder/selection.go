@@ -0,0 +1,90 @@
+package builder
+
+import (
+	"math/rand"
+
+	"github.com/aligator/tidal-playlist/internal/config"
+	"github.com/aligator/tidal-playlist/internal/models"
+)
+
+// SelectionStrategy decides which artists the tracks are collected from.
+// Every selected artist gets one track slot, so an artist may be selected
+// several times.
+type SelectionStrategy interface {
+	// Select returns count artists out of the filtered artists.
+	Select(rng *rand.Rand, artists []models.ArtistID, count int) []models.ArtistID
+}
+
+// newSelectionStrategy returns the configured strategy. tracksPerArtist
+// returns the per_artist limit of an artist.
+func newSelectionStrategy(cfg config.PlaylistConfig, tracksPerArtist func(artistID string) int) SelectionStrategy {
+	switch cfg.ArtistSelection {
+	case "even":
+		return EvenSelection{}
+	case "per_artist":
+		return PerArtistSelection{TracksPerArtist: tracksPerArtist}
+	default:
+		return UniformSelection{}
+	}
+}
+
+// UniformSelection picks every slot independently, so every artist is
+// equally likely for each of them.
+type UniformSelection struct{}
+
+// Select implements SelectionStrategy.
+func (UniformSelection) Select(rng *rand.Rand, artists []models.ArtistID, count int) []models.ArtistID {
+	return selectRandomItems(rng, count, artists)
+}
+
+// EvenSelection selects every artist once, in random order, before any
+// artist is selected again.
+type EvenSelection struct{}
+
+// Select implements SelectionStrategy.
+func (EvenSelection) Select(rng *rand.Rand, artists []models.ArtistID, count int) []models.ArtistID {
+	result := make([]models.ArtistID, 0, count)
+	for len(result) < count && len(artists) > 0 {
+		round := make([]models.ArtistID, len(artists))
+		copy(round, artists)
+		rng.Shuffle(len(round), func(i, j int) {
+			round[i], round[j] = round[j], round[i]
+		})
+
+		result = append(result, round[:min(len(round), count-len(result))]...)
+	}
+	return result
+}
+
+// PerArtistSelection selects artists like EvenSelection, but every artist at
+// most TracksPerArtist times. Fewer than count artists are returned if there
+// aren't enough artists for count.
+type PerArtistSelection struct {
+	TracksPerArtist func(artistID string) int
+}
+
+// Select implements SelectionStrategy.
+func (s PerArtistSelection) Select(rng *rand.Rand, artists []models.ArtistID, count int) []models.ArtistID {
+	selected := make(map[string]int)
+	result := make([]models.ArtistID, 0, count)
+	for len(result) < count {
+		var round []models.ArtistID
+		for _, artist := range artists {
+			if selected[artist.ID] < s.TracksPerArtist(artist.ID) {
+				round = append(round, artist)
+			}
+		}
+		if len(round) == 0 {
+			break
+		}
+		rng.Shuffle(len(round), func(i, j int) {
+			round[i], round[j] = round[j], round[i]
+		})
+
+		for _, artist := range round[:min(len(round), count-len(result))] {
+			selected[artist.ID]++
+			result = append(result, artist)
+		}
+	}
+	return result
+}
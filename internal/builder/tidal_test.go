@@ -0,0 +1,252 @@
+package builder
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aligator/tidal-playlist/internal/api"
+	"github.com/aligator/tidal-playlist/internal/config"
+)
+
+// fakeTidal is a minimal in-memory Tidal API serving the requests of a
+// build: the favorite artists with their albums and tracks, and the
+// playlists of the user.
+type fakeTidal struct {
+	mu sync.Mutex
+
+	// artists are the favorite artists, each with albumsPerArtist albums of
+	// tracksPerAlbum tracks.
+	artists         []string
+	albumsPerArtist int
+	tracksPerAlbum  int
+
+	playlists []*fakePlaylist
+	nextID    int
+}
+
+// fakePlaylist is a playlist of the fake user.
+type fakePlaylist struct {
+	ID          string
+	Name        string
+	Description string
+	Tracks      []string
+}
+
+// newFakeTidal returns a fake API with the given number of favorite artists.
+func newFakeTidal(artists, albumsPerArtist, tracksPerAlbum int) *fakeTidal {
+	f := &fakeTidal{albumsPerArtist: albumsPerArtist, tracksPerAlbum: tracksPerAlbum}
+	for i := range artists {
+		f.artists = append(f.artists, fmt.Sprintf("%d", 100+i))
+	}
+	return f
+}
+
+// addPlaylist adds an existing playlist of the user.
+func (f *fakeTidal) addPlaylist(name, description string) *fakePlaylist {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.createPlaylist(name, description)
+}
+
+func (f *fakeTidal) createPlaylist(name, description string) *fakePlaylist {
+	f.nextID++
+	playlist := &fakePlaylist{ID: fmt.Sprintf("playlist-%d", f.nextID), Name: name, Description: description}
+	f.playlists = append(f.playlists, playlist)
+	return playlist
+}
+
+// playlist returns the playlist with the given name, or nil.
+func (f *fakeTidal) playlist(name string) *fakePlaylist {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, playlist := range f.playlists {
+		if playlist.Name == name {
+			return playlist
+		}
+	}
+	return nil
+}
+
+func (f *fakeTidal) albumID(artistID string, album int) string {
+	return fmt.Sprintf("%s-%d", artistID, album)
+}
+
+func (f *fakeTidal) playlistResource(p *fakePlaylist) map[string]any {
+	return map[string]any{
+		"id":   p.ID,
+		"type": "playlists",
+		"attributes": map[string]any{
+			"name":          p.Name,
+			"description":   p.Description,
+			"createdAt":     time.Now().UTC().Format(time.RFC3339),
+			"numberOfItems": len(p.Tracks),
+		},
+	}
+}
+
+func (f *fakeTidal) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	var response any
+	switch {
+	case r.URL.Path == "/v2/users/me":
+		response = map[string]any{"data": map[string]any{"id": "user-1"}}
+
+	case len(parts) == 5 && parts[1] == "userCollections" && parts[4] == "artists":
+		var data []map[string]any
+		for _, id := range f.artists {
+			data = append(data, map[string]any{"id": id, "type": "artists"})
+		}
+		response = map[string]any{"data": data}
+
+	case len(parts) == 3 && parts[1] == "artists":
+		artistID := parts[2]
+		var albums, included []map[string]any
+		for i := range f.albumsPerArtist {
+			id := f.albumID(artistID, i)
+			albums = append(albums, map[string]any{"id": id, "type": "albums"})
+			included = append(included, map[string]any{
+				"id":   id,
+				"type": "albums",
+				"attributes": map[string]any{
+					"title":         fmt.Sprintf("Album %s", id),
+					"type":          "ALBUM",
+					"releaseDate":   fmt.Sprintf("%d-01-01", 2000+i),
+					"numberOfItems": f.tracksPerAlbum,
+				},
+			})
+		}
+		data := map[string]any{
+			"id":            artistID,
+			"type":          "artists",
+			"attributes":    map[string]any{"name": "Artist " + artistID},
+			"relationships": map[string]any{"albums": map[string]any{"data": albums}},
+		}
+		if r.URL.Query().Get("include") == "albums" {
+			response = map[string]any{"data": data, "included": included}
+		} else {
+			response = map[string]any{"data": data}
+		}
+
+	case len(parts) == 3 && parts[1] == "albums":
+		var included []map[string]any
+		for i := range f.tracksPerAlbum {
+			id := fmt.Sprintf("%s-%d", parts[2], i)
+			included = append(included, map[string]any{
+				"id":         id,
+				"type":       "tracks",
+				"attributes": map[string]any{"title": "Track " + id, "duration": "PT3M"},
+			})
+		}
+		response = map[string]any{"data": map[string]any{"id": parts[2]}, "included": included}
+
+	case r.URL.Path == "/v2/playlists" && r.Method == http.MethodGet:
+		data := []map[string]any{}
+		for _, playlist := range f.playlists {
+			data = append(data, f.playlistResource(playlist))
+		}
+		response = map[string]any{"data": data}
+
+	case r.URL.Path == "/v2/playlists" && r.Method == http.MethodPost:
+		var body struct {
+			Data struct {
+				Attributes struct {
+					Name        string `json:"name"`
+					Description string `json:"description"`
+				} `json:"attributes"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		playlist := f.createPlaylist(body.Data.Attributes.Name, body.Data.Attributes.Description)
+		w.WriteHeader(http.StatusCreated)
+		response = map[string]any{"data": f.playlistResource(playlist)}
+
+	case len(parts) == 3 && parts[1] == "playlists":
+		index := -1
+		for i, playlist := range f.playlists {
+			if playlist.ID == parts[2] {
+				index = i
+			}
+		}
+		if index < 0 {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			f.playlists = append(f.playlists[:index], f.playlists[index+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		response = map[string]any{"data": f.playlistResource(f.playlists[index])}
+
+	case len(parts) == 5 && parts[1] == "playlists" && parts[4] == "items" && r.Method == http.MethodPost:
+		var body struct {
+			Data []struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, playlist := range f.playlists {
+			if playlist.ID == parts[2] {
+				for _, item := range body.Data {
+					playlist.Tracks = append(playlist.Tracks, item.ID)
+				}
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// newTestBuilder returns a builder working against the fake API, with the
+// given YAML on top of the default config. The home directory is moved to a
+// temporary directory, so that no state of the user is read or written.
+func newTestBuilder(t *testing.T, fake *fakeTidal, configYAML string) *Builder {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, ".cache"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+
+	configPath := filepath.Join(home, "config.yaml")
+	configYAML = "tidal:\n  requests_per_second: 1000\n  max_retries: 0\n" + configYAML
+	if err := os.WriteFile(configPath, []byte(configYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	authMgr := api.NewAuthManager("client-id", "client-secret")
+	authMgr.UseToken("test-token", "")
+	client := api.NewClient(authMgr, cfg)
+	client.UseBaseURL(server.URL)
+	return NewBuilder(client, cfg)
+}
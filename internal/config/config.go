@@ -1,10 +1,19 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/aligator/tidal-playlist/internal/models"
+	"github.com/aligator/tidal-playlist/internal/version"
 	"github.com/spf13/viper"
 )
 
@@ -13,6 +22,9 @@ type Config struct {
 	Tidal    TidalConfig    `mapstructure:"tidal"`
 	Playlist PlaylistConfig `mapstructure:"playlist"`
 	Filters  FiltersConfig  `mapstructure:"filters"`
+	Builder  BuilderConfig  `mapstructure:"builder"`
+	Export   ExportConfig   `mapstructure:"export"`
+	Safety   SafetyConfig   `mapstructure:"safety"`
 }
 
 // TidalConfig holds Tidal API credentials.
@@ -20,18 +32,207 @@ type TidalConfig struct {
 	ClientID     string `mapstructure:"client_id"`
 	ClientSecret string `mapstructure:"client_secret"`
 	CountryCode  string `mapstructure:"country_code"`
+	Language     string `mapstructure:"language"`      // sent as Accept-Language if set
+	UserAgent    string `mapstructure:"user_agent"`    // sent as User-Agent
+	AccessToken  string `mapstructure:"access_token"`  // bypasses the saved token if set
+	RefreshToken string `mapstructure:"refresh_token"` // optional, used with AccessToken
+
+	// MaxConsecutiveFailures stops all requests after this many failed
+	// requests in a row. 0 disables the check.
+	MaxConsecutiveFailures int `mapstructure:"max_consecutive_failures"`
+
+	// RequestsPerSecond limits how fast requests are sent, and
+	// MaxConcurrent how many are in flight at once.
+	RequestsPerSecond float64 `mapstructure:"requests_per_second"`
+	MaxConcurrent     int     `mapstructure:"max_concurrent"`
+
+	// MaxRetries is how often a request is retried after a rate limit or
+	// temporary server error.
+	MaxRetries int `mapstructure:"max_retries"`
+
+	// StatusActions overrides how error statuses are handled, e.g.
+	// {"403": "reauth", "404": "fail"}. Actions are retry, reauth or fail.
+	StatusActions map[string]string `mapstructure:"status_actions"`
+
+	// AlbumFetchStrategy selects how the albums of an artist are fetched:
+	// include, relationship or auto (include with relationship fallback).
+	AlbumFetchStrategy string `mapstructure:"album_fetch_strategy"`
+
+	// TokenStore is where the OAuth token is saved: "file" or "keyring"
+	// for the OS keyring, falling back to the file if there is none.
+	TokenStore string `mapstructure:"token_store"`
+
+	// CallbackPort is the local port the OAuth login is redirected to,
+	// 0 picks a free port.
+	CallbackPort int `mapstructure:"callback_port"`
 }
 
 // PlaylistConfig holds playlist generation settings.
 type PlaylistConfig struct {
 	DefaultName string `mapstructure:"default_name"`
+	Source      string `mapstructure:"source"` // favorites or mix
+	MixID       string `mapstructure:"mix_id"` // used with source mix
+	NamePrefix  string `mapstructure:"name_prefix"`
+	NameSuffix  string `mapstructure:"name_suffix"`
+	Description string `mapstructure:"description"` // empty keeps the current description
 	Count       int    `mapstructure:"count"`
+
+	// TargetDuration switches from a fixed track count to collecting tracks
+	// until their total duration reaches this value.
+	TargetDuration time.Duration `mapstructure:"target_duration"`
+
+	TrackStrategy    string `mapstructure:"track_strategy"` // random or artist_top
+	TracksPerArtist  int    `mapstructure:"tracks_per_artist"`
+	AlbumWeighting   string `mapstructure:"album_weighting"`    // uniform or tracks
+	PreferRecent     bool   `mapstructure:"prefer_recent"`      // favor newer albums
+	PreferClassics   bool   `mapstructure:"prefer_classics"`    // favor older albums
+	MinTracks        int    `mapstructure:"min_tracks"`         // 0 means no floor
+	SparePercent     int    `mapstructure:"spare_percent"`      // extra candidates to fill dropped tracks
+	MaxSize          int    `mapstructure:"max_size"`           // split into numbered playlists above this, 0 means no limit
+	MaxAlbumsFetched int    `mapstructure:"max_albums_fetched"` // 0 means unlimited
+	KeepHistory      int    `mapstructure:"keep_history"`       // 0 means overwrite by name
+	AlsoLike         bool   `mapstructure:"also_like"`          // add all tracks to the favorites
+	SkipUnchanged    bool   `mapstructure:"skip_unchanged"`     // keep the playlist if the tracks are the same
+	AddRetries       int    `mapstructure:"add_retries"`        // retries for adding the remaining tracks
+	CleanupOnFailure bool   `mapstructure:"cleanup_on_failure"` // delete partially written playlists
+
+	// Mode decides what happens to an existing playlist of the same name:
+	// "replace" deletes and recreates it, "append" adds the new tracks to it.
+	Mode string `mapstructure:"mode"`
+
+	// AccessType of created playlists: PUBLIC can be found by everyone,
+	// UNLISTED only opened with the link. Empty leaves it to Tidal.
+	AccessType string `mapstructure:"access_type"`
+
+	// ArtistSelection decides how the artists are picked for the track
+	// slots: "uniform" picks each slot independently, "even" uses every
+	// artist once before any artist is used again, "per_artist" like even
+	// but every artist at most TracksPerArtist times.
+	ArtistSelection string `mapstructure:"artist_selection"`
+
+	// ArtistOverrides changes the track strategy and tracks per artist for
+	// single artists, keyed by artist ID or name. An override takes
+	// precedence over the global settings, and an ID over a name.
+	ArtistOverrides map[string]ArtistOverride `mapstructure:"artist_overrides"`
+
+	// MaxTracksPerAlbum limits how many tracks are taken from any one album
+	// (random strategy only), 0 means no limit.
+	MaxTracksPerAlbum int `mapstructure:"max_tracks_per_album"`
+
+	// DedupBy decides when two tracks count as the same song: "id" only if
+	// they are the same track, "title_artist" also if they are releases of
+	// the same song by the same artist, e.g. a remaster.
+	DedupBy string `mapstructure:"dedup_by"`
+
+	// AllowDuplicates keeps tracks that were selected more than once,
+	// disabling dedup_by.
+	AllowDuplicates bool `mapstructure:"allow_duplicates"`
+
+	// Seed makes the random selection reproducible, 0 picks a new seed for
+	// every run. The same seed and the same library result in the same
+	// playlist.
+	Seed int64 `mapstructure:"seed"`
+
+	// Profiles merges the favorite artists of these profiles, each logged in
+	// with "auth --profile", into one pool. The playlist is written to the
+	// account of the current profile.
+	Profiles []string `mapstructure:"profiles"`
+
+	// PauseBetween is waited between the playlists of a multi-playlist run.
+	PauseBetween time.Duration `mapstructure:"pause_between"`
+
+	// OnlyIfChanged skips the build if the favorite artists are the same
+	// as when the playlist was last built.
+	OnlyIfChanged bool `mapstructure:"only_if_changed"`
+
+	// VerifyAvailability re-checks all tracks right before writing and
+	// replaces the ones that became unavailable.
+	VerifyAvailability bool `mapstructure:"verify_availability"`
+}
+
+// ArtistOverride holds the playlist settings overridden for one artist.
+// Empty or zero fields keep the global setting.
+type ArtistOverride struct {
+	TrackStrategy   string `mapstructure:"track_strategy"`    // random or artist_top
+	TracksPerArtist int    `mapstructure:"tracks_per_artist"` // top tracks to choose from, or the per_artist limit
 }
 
-// FiltersConfig holds artist filtering settings.
+// FiltersConfig holds artist and album filtering settings.
 type FiltersConfig struct {
-	Blacklist []string `mapstructure:"blacklist"`
-	Whitelist []string `mapstructure:"whitelist"`
+	Blacklist     []string `mapstructure:"blacklist"`
+	Whitelist     []string `mapstructure:"whitelist"`
+	Labels        []string `mapstructure:"labels"`
+	ExcludeLabels []string `mapstructure:"exclude_labels"`
+	ArtistRoles   []string `mapstructure:"artist_roles"` // e.g. MAIN, empty means all roles
+
+	ExcludeExplicit       bool `mapstructure:"exclude_explicit"`        // skip explicit tracks
+	ExcludeExplicitAlbums bool `mapstructure:"exclude_explicit_albums"` // skip whole explicit albums
+	ExcludeSingles        bool `mapstructure:"exclude_singles"`         // skip albums of type SINGLE
+
+	// MatchBy decides whether whitelist and blacklist entries are matched
+	// against artist IDs, names or both.
+	MatchBy string `mapstructure:"match_by"`
+
+	// MinQuality skips tracks not available in at least this audio quality:
+	// LOW, HIGH, LOSSLESS or HIRES_LOSSLESS.
+	MinQuality string `mapstructure:"min_quality"`
+
+	// MinArtistPopularity skips artists whose popularity on Tidal, from 0
+	// to 1, is below this value. 0 disables the filter.
+	MinArtistPopularity float64 `mapstructure:"min_artist_popularity"`
+
+	// RelaxForEmptyArtists ignores the type and label filters for artists
+	// that would have no albums left otherwise.
+	RelaxForEmptyArtists bool `mapstructure:"relax_for_empty_artists"`
+
+	// ExcludeInPlaylists lists playlist names or IDs whose tracks must not
+	// be selected.
+	ExcludeInPlaylists []string `mapstructure:"exclude_in_playlists"`
+
+	// Pipeline lists the filters to apply, in this order. Filters missing
+	// from the list are disabled. Empty means all in the order of
+	// FilterNames.
+	Pipeline []string `mapstructure:"pipeline"`
+
+	// ExplainSkips prints every rejection by a filter together with the rule
+	// and a summary per rule at the end.
+	ExplainSkips bool `mapstructure:"explain_skips"`
+}
+
+// BuilderConfig holds settings for how the builder deals with problems.
+type BuilderConfig struct {
+	// OnMissingAlbums decides what happens if an artist has no albums at all:
+	// "skip" silently, "warn" and continue or fail the build with "error".
+	OnMissingAlbums string `mapstructure:"on_missing_albums"`
+}
+
+// FilterNames are the names of all filters, in their default order.
+var FilterNames = []string{
+	"artist_roles",
+	"whitelist",
+	"blacklist",
+	"exclude_explicit_albums",
+	"exclude_singles",
+	"labels",
+	"exclude_labels",
+	"exclude_explicit",
+	"min_quality",
+	"exclude_in_playlists",
+	"min_artist_popularity",
+}
+
+// ExportConfig holds settings for exported files.
+type ExportConfig struct {
+	ImageSize int `mapstructure:"image_size"` // preferred cover width in pixels
+
+	// BOM prepends a UTF-8 byte order mark to exported M3U files.
+	BOM bool `mapstructure:"bom"`
+}
+
+// SafetyConfig holds settings protecting against unwanted changes.
+type SafetyConfig struct {
+	// DryRunByDefault makes create only preview unless --commit is passed.
+	DryRunByDefault bool `mapstructure:"dry_run_by_default"`
 }
 
 // Load loads configuration from file and environment.
@@ -40,10 +241,63 @@ func Load(configPath string) (*Config, error) {
 
 	// Set defaults
 	v.SetDefault("tidal.country_code", "US")
+	v.SetDefault("tidal.max_consecutive_failures", 5)
+	v.SetDefault("tidal.user_agent", version.UserAgent())
+	v.SetDefault("tidal.album_fetch_strategy", "auto")
+	v.SetDefault("tidal.max_retries", 3)
+	v.SetDefault("tidal.requests_per_second", 3)
+	v.SetDefault("tidal.max_concurrent", 1)
+	v.SetDefault("tidal.token_store", "file")
+	v.SetDefault("tidal.callback_port", 8080)
 	v.SetDefault("playlist.default_name", "My Artists Mix")
+	v.SetDefault("playlist.source", "favorites")
+	v.SetDefault("playlist.track_strategy", "random")
 	v.SetDefault("playlist.tracks_per_artist", 5)
+	v.SetDefault("playlist.album_weighting", "uniform")
+	v.SetDefault("playlist.dedup_by", "id")
+	v.SetDefault("playlist.mode", "replace")
+	v.SetDefault("playlist.artist_selection", "uniform")
+	v.SetDefault("playlist.pause_between", 2*time.Second)
+	v.SetDefault("builder.on_missing_albums", "warn")
+	v.SetDefault("filters.match_by", "both")
+	v.SetDefault("export.image_size", 640)
 	v.SetDefault("playlist.total_track_limit", 500)
 
+	if err := readConfig(v, configPath); err != nil {
+		return nil, err
+	}
+
+	// Environment variables override config file
+	v.SetEnvPrefix("TIDAL")
+	v.AutomaticEnv()
+	v.BindEnv("tidal.access_token", "TIDAL_ACCESS_TOKEN")
+	v.BindEnv("tidal.refresh_token", "TIDAL_REFRESH_TOKEN")
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+// readConfig reads the config into v. The path may be "-" to read from stdin
+// or an http(s) URL to fetch the config from. Without a path, the default
+// locations are searched and a missing config file is not an error.
+func readConfig(v *viper.Viper, configPath string) error {
+	if configPath == "-" || strings.HasPrefix(configPath, "http://") || strings.HasPrefix(configPath, "https://") {
+		data, err := fetchConfig(configPath)
+		if err != nil {
+			return err
+		}
+
+		v.SetConfigType("yaml")
+		if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
+		return nil
+	}
+
 	// Try to read config file
 	if configPath != "" {
 		v.SetConfigFile(configPath)
@@ -62,21 +316,40 @@ func Load(configPath string) (*Config, error) {
 	// Read config file if it exists
 	if err := v.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return nil, fmt.Errorf("failed to read config file: %w", err)
+			return fmt.Errorf("failed to read config file: %w", err)
 		}
 		// Config file not found is okay, we'll use defaults
 	}
 
-	// Environment variables override config file
-	v.SetEnvPrefix("TIDAL")
-	v.AutomaticEnv()
+	return nil
+}
 
-	var cfg Config
-	if err := v.Unmarshal(&cfg); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+// fetchConfig reads the raw config from stdin or from a URL.
+func fetchConfig(configPath string) ([]byte, error) {
+	if configPath == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config from stdin: %w", err)
+		}
+		return data, nil
 	}
 
-	return &cfg, nil
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch config: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch config: %s returned status %d", configPath, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch config: %w", err)
+	}
+	return data, nil
 }
 
 // Validate checks if the configuration is valid.
@@ -87,9 +360,139 @@ func (c *Config) Validate() error {
 	if c.Tidal.ClientSecret == "" {
 		return fmt.Errorf("tidal.client_secret is required")
 	}
-	if c.Playlist.Count < 1 {
+	switch c.Tidal.AlbumFetchStrategy {
+	case "include", "relationship", "auto":
+	default:
+		return fmt.Errorf("tidal.album_fetch_strategy must be 'include', 'relationship' or 'auto'")
+	}
+	if c.Tidal.TokenStore != "file" && c.Tidal.TokenStore != "keyring" {
+		return fmt.Errorf("tidal.token_store must be 'file' or 'keyring'")
+	}
+	if c.Tidal.CallbackPort < 0 || c.Tidal.CallbackPort > 65535 {
+		return fmt.Errorf("tidal.callback_port must be between 0 and 65535")
+	}
+	if c.Tidal.RequestsPerSecond <= 0 {
+		return fmt.Errorf("tidal.requests_per_second must be greater than 0")
+	}
+	if c.Tidal.MaxConcurrent < 1 {
+		return fmt.Errorf("tidal.max_concurrent must be at least 1")
+	}
+	if c.Tidal.MaxRetries < 0 {
+		return fmt.Errorf("tidal.max_retries must not be negative")
+	}
+	for status, action := range c.Tidal.StatusActions {
+		if code, err := strconv.Atoi(status); err != nil || code < 400 || code > 599 {
+			return fmt.Errorf("tidal.status_actions: '%s' is not an error status code", status)
+		}
+		if action != "retry" && action != "reauth" && action != "fail" {
+			return fmt.Errorf("tidal.status_actions: action for %s must be 'retry', 'reauth' or 'fail'", status)
+		}
+	}
+	if c.Playlist.TargetDuration < 0 {
+		return fmt.Errorf("playlist.target_duration must not be negative")
+	}
+	if c.Playlist.TargetDuration == 0 && c.Playlist.Count < 1 {
 		return fmt.Errorf("playlist.count must be at least 1")
 	}
+	if c.Playlist.Source != "favorites" && c.Playlist.Source != "mix" {
+		return fmt.Errorf("playlist.source must be 'favorites' or 'mix'")
+	}
+	if c.Playlist.Source == "mix" && c.Playlist.MixID == "" {
+		return fmt.Errorf("playlist.mix_id is required for source 'mix'")
+	}
+	if c.Playlist.OnlyIfChanged && c.Playlist.Source != "favorites" {
+		return fmt.Errorf("playlist.only_if_changed requires source 'favorites'")
+	}
+	if c.Playlist.TrackStrategy != "random" && c.Playlist.TrackStrategy != "artist_top" {
+		return fmt.Errorf("playlist.track_strategy must be 'random' or 'artist_top'")
+	}
+	if c.Playlist.AlbumWeighting != "uniform" && c.Playlist.AlbumWeighting != "tracks" {
+		return fmt.Errorf("playlist.album_weighting must be 'uniform' or 'tracks'")
+	}
+	switch c.Playlist.AccessType {
+	case "", "PUBLIC", "UNLISTED":
+	case "PRIVATE":
+		return fmt.Errorf("playlist.access_type PRIVATE is not supported by the Tidal API, use UNLISTED")
+	default:
+		return fmt.Errorf("playlist.access_type must be 'PUBLIC' or 'UNLISTED'")
+	}
+	if !slices.Contains([]string{"uniform", "even", "per_artist"}, c.Playlist.ArtistSelection) {
+		return fmt.Errorf("playlist.artist_selection must be 'uniform', 'even' or 'per_artist'")
+	}
+	if c.Playlist.DedupBy != "id" && c.Playlist.DedupBy != "title_artist" {
+		return fmt.Errorf("playlist.dedup_by must be 'id' or 'title_artist'")
+	}
+	if c.Playlist.PreferRecent && c.Playlist.PreferClassics {
+		return fmt.Errorf("playlist.prefer_recent and playlist.prefer_classics are mutually exclusive")
+	}
+	if c.Playlist.TracksPerArtist < 1 {
+		return fmt.Errorf("playlist.tracks_per_artist must be at least 1")
+	}
+	for key, override := range c.Playlist.ArtistOverrides {
+		switch override.TrackStrategy {
+		case "", "random", "artist_top":
+		default:
+			return fmt.Errorf("playlist.artist_overrides.%s.track_strategy must be 'random' or 'artist_top'", key)
+		}
+		if override.TracksPerArtist < 0 {
+			return fmt.Errorf("playlist.artist_overrides.%s.tracks_per_artist must not be negative", key)
+		}
+	}
+	if c.Playlist.MinTracks < 0 {
+		return fmt.Errorf("playlist.min_tracks must not be negative")
+	}
+	if c.Playlist.TargetDuration == 0 && c.Playlist.MinTracks > c.Playlist.Count {
+		return fmt.Errorf("playlist.min_tracks (%d) must not exceed playlist.count (%d)", c.Playlist.MinTracks, c.Playlist.Count)
+	}
+	switch c.Builder.OnMissingAlbums {
+	case "skip", "warn", "error":
+	default:
+		return fmt.Errorf("builder.on_missing_albums must be 'skip', 'warn' or 'error'")
+	}
+	if c.Export.ImageSize < 0 {
+		return fmt.Errorf("export.image_size must not be negative")
+	}
+	if c.Filters.MinQuality != "" && models.QualityRank(c.Filters.MinQuality) == 0 {
+		return fmt.Errorf("filters.min_quality must be one of LOW, HIGH, LOSSLESS or HIRES_LOSSLESS")
+	}
+	if c.Filters.MatchBy != "id" && c.Filters.MatchBy != "name" && c.Filters.MatchBy != "both" {
+		return fmt.Errorf("filters.match_by must be 'id', 'name' or 'both'")
+	}
+	if c.Filters.MinArtistPopularity < 0 || c.Filters.MinArtistPopularity > 1 {
+		return fmt.Errorf("filters.min_artist_popularity must be between 0 and 1")
+	}
+	for _, name := range c.Filters.Pipeline {
+		if !slices.Contains(FilterNames, name) {
+			return fmt.Errorf("filters.pipeline contains unknown filter '%s', must be one of %s", name, strings.Join(FilterNames, ", "))
+		}
+	}
+	if c.Playlist.SparePercent < 0 {
+		return fmt.Errorf("playlist.spare_percent must not be negative")
+	}
+	if c.Playlist.MaxSize < 0 {
+		return fmt.Errorf("playlist.max_size must not be negative")
+	}
+	if c.Playlist.PauseBetween < 0 {
+		return fmt.Errorf("playlist.pause_between must not be negative")
+	}
+	if c.Playlist.AddRetries < 0 {
+		return fmt.Errorf("playlist.add_retries must not be negative")
+	}
+	if c.Playlist.MaxAlbumsFetched < 0 {
+		return fmt.Errorf("playlist.max_albums_fetched must not be negative")
+	}
+	if c.Playlist.MaxTracksPerAlbum < 0 {
+		return fmt.Errorf("playlist.max_tracks_per_album must not be negative")
+	}
+	if c.Playlist.KeepHistory < 0 {
+		return fmt.Errorf("playlist.keep_history must not be negative")
+	}
+	if c.Playlist.Mode != "replace" && c.Playlist.Mode != "append" {
+		return fmt.Errorf("playlist.mode must be 'replace' or 'append'")
+	}
+	if c.Playlist.Mode == "append" && (c.Playlist.MaxSize > 0 || c.Playlist.KeepHistory > 0) {
+		return fmt.Errorf("playlist.mode 'append' can't be used with max_size or keep_history")
+	}
 
 	return nil
 }
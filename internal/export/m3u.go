@@ -0,0 +1,69 @@
+// Package export writes playlists to files for use outside of Tidal.
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aligator/tidal-playlist/internal/models"
+)
+
+// trackURL is the location written for every track, as the tracks only
+// exist on Tidal.
+const trackURL = "https://tidal.com/browse/track/"
+
+// utf8BOM marks a file as UTF-8 for tools that would guess another encoding.
+const utf8BOM = "\uFEFF"
+
+// WriteM3U writes the tracks as extended M3U8 playlist to path. Every track
+// gets an #EXTINF line with its duration and "Artist - Title". With bom set,
+// the file starts with a UTF-8 byte order mark.
+func WriteM3U(path, playlistName string, tracks []models.Track, bom bool) error {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	if bom {
+		if _, err := w.WriteString(utf8BOM); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(w, "#EXTM3U")
+	fmt.Fprintf(w, "#PLAYLIST:%s\n", playlistName)
+	for _, track := range tracks {
+		fmt.Fprintf(w, "#EXTINF:%d,%s\n", track.Duration, displayName(track))
+		fmt.Fprintln(w, trackURL+track.ID)
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// displayName returns "Artist - Title", or only the title if the artists
+// are unknown.
+func displayName(track models.Track) string {
+	var names []string
+	for _, artist := range track.Artists {
+		if artist.Attributes.Name != "" {
+			names = append(names, artist.Attributes.Name)
+		}
+	}
+	if len(names) == 0 {
+		return track.Title
+	}
+	return strings.Join(names, ", ") + " - " + track.Title
+}
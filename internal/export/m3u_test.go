@@ -0,0 +1,109 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/aligator/tidal-playlist/internal/models"
+)
+
+// m3uEntry is a track as read back from an M3U file.
+type m3uEntry struct {
+	duration int
+	name     string
+	location string
+}
+
+// readM3U parses a file written by WriteM3U. It reports whether the file
+// starts with a byte order mark.
+func readM3U(t *testing.T, path string) (name string, entries []m3uEntry, bom bool) {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !utf8.Valid(data) {
+		t.Fatalf("%s is no valid UTF-8", path)
+	}
+	data, bom = bytes.CutPrefix(data, []byte(utf8BOM))
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	if !scanner.Scan() || scanner.Text() != "#EXTM3U" {
+		t.Fatalf("missing #EXTM3U header")
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "#PLAYLIST:"):
+			name = strings.TrimPrefix(line, "#PLAYLIST:")
+		case strings.HasPrefix(line, "#EXTINF:"):
+			duration, title, ok := strings.Cut(strings.TrimPrefix(line, "#EXTINF:"), ",")
+			if !ok {
+				t.Fatalf("malformed line %q", line)
+			}
+			seconds, err := strconv.Atoi(duration)
+			if err != nil {
+				t.Fatalf("malformed duration in %q: %v", line, err)
+			}
+			entries = append(entries, m3uEntry{duration: seconds, name: title})
+		default:
+			if len(entries) == 0 || entries[len(entries)-1].location != "" {
+				t.Fatalf("location %q without #EXTINF line", line)
+			}
+			entries[len(entries)-1].location = line
+		}
+	}
+	return name, entries, bom
+}
+
+func TestWriteM3URoundTrip(t *testing.T) {
+	artist := func(name string) models.Artist {
+		var a models.Artist
+		a.Attributes.Name = name
+		return a
+	}
+	tracks := []models.Track{
+		{ID: "1", Title: "Fire 🔥 Dance 💃", Duration: 215, Artists: []models.Artist{artist("DJ 🎧")}},
+		{ID: "2", Title: "夜に駆ける", Duration: 261, Artists: []models.Artist{artist("YOASOBI")}},
+		{ID: "3", Title: "강남스타일", Duration: 219, Artists: []models.Artist{artist("싸이"), artist("PSY")}},
+		{ID: "4", Title: "月亮代表我的心", Duration: 208},
+	}
+	want := []m3uEntry{
+		{215, "DJ 🎧 - Fire 🔥 Dance 💃", trackURL + "1"},
+		{261, "YOASOBI - 夜に駆ける", trackURL + "2"},
+		{219, "싸이, PSY - 강남스타일", trackURL + "3"},
+		{208, "月亮代表我的心", trackURL + "4"},
+	}
+
+	for _, bom := range []bool{false, true} {
+		t.Run("bom="+strconv.FormatBool(bom), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "exports", "mix.m3u8")
+			if err := WriteM3U(path, "東京 Mix 🌸", tracks, bom); err != nil {
+				t.Fatalf("WriteM3U failed: %v", err)
+			}
+
+			name, entries, gotBOM := readM3U(t, path)
+			if gotBOM != bom {
+				t.Errorf("byte order mark = %v, want %v", gotBOM, bom)
+			}
+			if name != "東京 Mix 🌸" {
+				t.Errorf("playlist name = %q", name)
+			}
+			if len(entries) != len(want) {
+				t.Fatalf("got %d entries, want %d", len(entries), len(want))
+			}
+			for i := range want {
+				if entries[i] != want[i] {
+					t.Errorf("entry %d = %+v, want %+v", i, entries[i], want[i])
+				}
+			}
+		})
+	}
+}
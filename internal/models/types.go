@@ -4,14 +4,16 @@ import "time"
 
 // ArtistID represents a Tidal artist with id only
 type ArtistID struct {
-	ID string `json:"id"`
+	ID    string   `json:"id"`
+	Roles []string `json:"roles,omitempty"` // e.g. MAIN or COMPOSER, if known
 }
 
 // Artist represents a Tidal artist
 type Artist struct {
 	ID         string `json:"id"`
 	Attributes struct {
-		Name string `json:"name"`
+		Name       string  `json:"name"`
+		Popularity float64 `json:"popularity,omitempty"` // 0 to 1, 0 if unknown
 	} `json:"attributes"`
 }
 
@@ -19,20 +21,90 @@ type Artist struct {
 type Track struct {
 	ID          string   `json:"id"`
 	Title       string   `json:"title"`
-	Duration    int      `json:"duration"`
+	Duration    int      `json:"duration"` // in seconds
+	Explicit    bool     `json:"explicit,omitempty"`
+	ISRC        string   `json:"isrc,omitempty"`
+	Quality     string   `json:"quality,omitempty"` // best available audio quality, empty if unknown
 	TrackNumber int      `json:"trackNumber,omitempty"`
 	ArtistID    string   `json:"artistId,omitempty"`
 	AlbumID     string   `json:"albumId,omitempty"`
 	Artists     []Artist `json:"artists,omitempty"`
 }
 
+// Audio quality tiers offered by Tidal, from lowest to highest
+const (
+	QualityLow           = "LOW"
+	QualityHigh          = "HIGH"
+	QualityLossless      = "LOSSLESS"
+	QualityHiResLossless = "HIRES_LOSSLESS"
+)
+
+// QualityRank returns the position of a quality tier, higher is better.
+// Unknown values rank 0
+func QualityRank(quality string) int {
+	switch quality {
+	case QualityLow:
+		return 1
+	case QualityHigh:
+		return 2
+	case QualityLossless:
+		return 3
+	case QualityHiResLossless:
+		return 4
+	}
+	return 0
+}
+
 // Album represents a Tidal album
 type Album struct {
 	ID             string   `json:"id"`
 	Title          string   `json:"title"`
+	Type           string   `json:"type,omitempty"` // ALBUM, EP or SINGLE
+	Explicit       bool     `json:"explicit,omitempty"`
 	Artists        []Artist `json:"artists,omitempty"`
 	ReleaseDate    string   `json:"releaseDate,omitempty"`
 	NumberOfTracks int      `json:"numberOfTracks,omitempty"`
+	Copyright      string   `json:"copyright,omitempty"` // usually contains the label
+	Images         []Image  `json:"images,omitempty"`
+}
+
+// DisplayTitle returns the album title for output. Albums without a title
+// are shown as "(untitled <id>)", while Title stays empty so that nothing
+// matches against a made up title
+func (a *Album) DisplayTitle() string {
+	if a.Title != "" {
+		return a.Title
+	}
+	return "(untitled " + a.ID + ")"
+}
+
+// Image represents one size of a cover image
+type Image struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+}
+
+// PreferredImage returns the URL of the image whose width is closest to the
+// given width, or an empty string if there are no images
+func PreferredImage(images []Image, width int) string {
+	best := -1
+	for i, image := range images {
+		if best < 0 || abs(image.Width-width) < abs(images[best].Width-width) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return ""
+	}
+	return images[best].URL
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
 }
 
 // Playlist represents a Tidal playlist
@@ -46,6 +118,8 @@ type Playlist struct {
 	Created        time.Time `json:"created,omitempty"`
 	LastUpdated    time.Time `json:"lastUpdated,omitempty"`
 	NumberOfTracks int       `json:"numberOfTracks,omitempty"`
+	AccessType     string    `json:"accessType,omitempty"` // PUBLIC or UNLISTED
+	Images         []Image   `json:"images,omitempty"`
 }
 
 // GetID returns the playlist ID (prefers ID over UUID)
@@ -64,6 +138,11 @@ func (p *Playlist) GetTitle() string {
 	return p.Name
 }
 
+// URL returns the link to open the playlist in Tidal
+func (p *Playlist) URL() string {
+	return "https://tidal.com/browse/playlist/" + p.GetID()
+}
+
 // Creator represents the creator of a playlist
 type Creator struct {
 	ID   string `json:"id"`
@@ -72,8 +151,9 @@ type Creator struct {
 
 // PlaylistItem represents an item in a playlist
 type PlaylistItem struct {
-	ItemID string `json:"itemId"`
-	Type   string `json:"type"` // Usually "track"
+	ID     string `json:"id"`     // ID of the track or video
+	ItemID string `json:"itemId"` // ID of the entry within the playlist
+	Type   string `json:"type"`   // Usually "tracks"
 }
 
 // APIResponse represents a generic API response with pagination
@@ -84,9 +164,15 @@ type APIResponse struct {
 
 // Metadata represents pagination metadata
 type Metadata struct {
-	Total  int `json:"total"`
-	Offset int `json:"offset,omitempty"`
-	Limit  int `json:"limit,omitempty"`
+	Total      int    `json:"total"`
+	Offset     int    `json:"offset,omitempty"`
+	Limit      int    `json:"limit,omitempty"`
+	NextCursor string `json:"nextCursor,omitempty"` // empty on the last page
+}
+
+// HasMore reports whether there are more pages after this one
+func (m Metadata) HasMore() bool {
+	return m.NextCursor != ""
 }
 
 // ErrorResponse represents an API error response
@@ -0,0 +1,20 @@
+package models
+
+import "testing"
+
+func TestAlbumDisplayTitle(t *testing.T) {
+	tests := []struct {
+		album Album
+		want  string
+	}{
+		{Album{ID: "1", Title: "OK Computer"}, "OK Computer"},
+		{Album{ID: "2"}, "(untitled 2)"},
+		{Album{ID: "3", Title: "夜に駆ける"}, "夜に駆ける"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.album.DisplayTitle(); got != tt.want {
+			t.Errorf("DisplayTitle() of %+v = %q, want %q", tt.album, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Package queue stores playlist writes deferred with "create --defer-write",
+// so that they can be written later with "flush-queue".
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// SchemaVersion is the version of the queue file format.
+const SchemaVersion = 1
+
+// Queue is the list of playlist writes waiting to be done.
+type Queue struct {
+	SchemaVersion int     `json:"schema_version"`
+	Writes        []Write `json:"writes"`
+}
+
+// Write is a playlist waiting to be written.
+type Write struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"` // empty keeps the current description
+	TrackIDs    []string  `json:"track_ids"`
+	Queued      time.Time `json:"queued"`
+
+	// StateKey, FavoritesHash and FailedArtists are the state of the
+	// build, saved once the playlist is written. Only the last part of a
+	// split playlist carries them.
+	StateKey      string   `json:"state_key,omitempty"`
+	FavoritesHash string   `json:"favorites_hash,omitempty"`
+	FailedArtists []string `json:"failed_artists,omitempty"`
+}
+
+// Load reads the queue at path. A missing file results in an empty queue.
+func Load(path string) (*Queue, error) {
+	q := &Queue{}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, q); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if q.SchemaVersion > SchemaVersion {
+		return nil, fmt.Errorf("%s was written by a newer version (schema %d)", path, q.SchemaVersion)
+	}
+	return q, nil
+}
+
+// Add queues a write. An earlier write of a playlist with the same name is
+// dropped, as the new one would replace it anyway.
+func (q *Queue) Add(w Write) {
+	q.Writes = slices.DeleteFunc(q.Writes, func(queued Write) bool {
+		return queued.Name == w.Name
+	})
+	q.Writes = append(q.Writes, w)
+}
+
+// Save writes the queue to path.
+func (q *Queue) Save(path string) error {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	q.SchemaVersion = SchemaVersion
+	data, err := json.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,70 @@
+// Package recipe reads and writes recipes, the exact track selection of a
+// build, so that the same playlist can be written again elsewhere.
+package recipe
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aligator/tidal-playlist/internal/config"
+)
+
+// SchemaVersion is the version of the recipe file format.
+const SchemaVersion = 1
+
+// Recipe is the selection of a single build.
+type Recipe struct {
+	SchemaVersion int                  `json:"schema_version"`
+	Name          string               `json:"name"`
+	Created       time.Time            `json:"created"`
+	Seed          int64                `json:"seed"`
+	Filters       config.FiltersConfig `json:"filters"` // for reference only, not applied again
+	Tracks        []Track              `json:"tracks"`
+}
+
+// Track is a selected track.
+type Track struct {
+	ID    string `json:"id"`
+	Title string `json:"title,omitempty"`
+}
+
+// Write stores the recipe as JSON file at path.
+func Write(path string, r Recipe) error {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	r.SchemaVersion = SchemaVersion
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// Read reads the recipe at path.
+func Read(path string) (*Recipe, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var r Recipe
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if r.SchemaVersion > SchemaVersion {
+		return nil, fmt.Errorf("%s was written by a newer version (schema %d)", path, r.SchemaVersion)
+	}
+	if len(r.Tracks) == 0 {
+		return nil, fmt.Errorf("%s contains no tracks", path)
+	}
+
+	return &r, nil
+}
@@ -0,0 +1,69 @@
+// Package state persists information between runs, e.g. to detect changes
+// since the last run.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// State is the information kept between runs.
+type State struct {
+	// FavoritesHashes contains per playlist name the hash of the favorite
+	// artists the playlist was last built from.
+	FavoritesHashes map[string]string `json:"favorites_hashes,omitempty"`
+
+	// FavoriteSnapshots contains per playlist name the favorite artist IDs
+	// known when new favorites were last appended to it.
+	FavoriteSnapshots map[string][]string `json:"favorite_snapshots,omitempty"`
+
+	// Failures contains per playlist name the artists that contributed no
+	// track to the last build.
+	Failures map[string]Failure `json:"failures,omitempty"`
+}
+
+// Failure records the artists missing from a built playlist.
+type Failure struct {
+	PlaylistID string   `json:"playlist_id"`
+	ArtistIDs  []string `json:"artist_ids"`
+}
+
+// DefaultPath returns the location of the state file in the config directory.
+func DefaultPath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".config", "tidal-playlist", "state.json")
+}
+
+// Load reads the state from path. A missing file results in an empty state.
+func Load(path string) (*State, error) {
+	s := &State{}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Save writes the state to path.
+func (s *State) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0600)
+}
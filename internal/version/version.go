@@ -0,0 +1,11 @@
+// Package version holds the version of tidal-playlist.
+package version
+
+// Version is the version of tidal-playlist, may be overridden at build time
+// with -ldflags "-X github.com/aligator/tidal-playlist/internal/version.Version=...".
+var Version = "0.1.0"
+
+// UserAgent returns the default User-Agent sent with every request.
+func UserAgent() string {
+	return "tidal-playlist/" + Version
+}